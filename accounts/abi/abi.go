@@ -22,6 +22,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"sort"
+	"strings"
 
 	"github.com/youngqqcn/arbitrum/common"
 	"github.com/youngqqcn/arbitrum/crypto"
@@ -211,6 +213,64 @@ func (abi *ABI) MethodById(sigdata []byte) (*Method, error) {
 	return nil, fmt.Errorf("no method with id: %#x", sigdata[:4])
 }
 
+// DecodeCall matches the selector of the given calldata against the ABI and
+// unpacks the call arguments, keyed by argument name. Unnamed arguments are
+// keyed by their position, e.g. arg0.
+func DecodeCall(abi *ABI, data []byte) (method Method, args map[string]interface{}, err error) {
+	m, err := abi.MethodById(data)
+	if err != nil {
+		return Method{}, nil, err
+	}
+	values, err := m.Inputs.Unpack(data[4:])
+	if err != nil {
+		return Method{}, nil, fmt.Errorf("abi: failed to unpack %s arguments: %v", m.Sig, err)
+	}
+	args = make(map[string]interface{}, len(values))
+	for i, input := range m.Inputs {
+		args[callArgName(input, i)] = values[i]
+	}
+	return *m, args, nil
+}
+
+// FormatCall returns a human-readable summary of a call decoded by DecodeCall,
+// e.g. transfer(to: 0x..., value: 100).
+func FormatCall(method Method, args map[string]interface{}) string {
+	fields := make([]string, len(method.Inputs))
+	for i, input := range method.Inputs {
+		name := callArgName(input, i)
+		fields[i] = fmt.Sprintf("%s: %v", name, args[name])
+	}
+	return fmt.Sprintf("%s(%s)", method.RawName, strings.Join(fields, ", "))
+}
+
+// callArgName returns the map key of the i-th argument of a decoded call.
+func callArgName(arg Argument, i int) string {
+	if arg.Name == "" {
+		return fmt.Sprintf("arg%d", i)
+	}
+	return arg.Name
+}
+
+// MethodByRawName returns all overloads of the method with the given raw name,
+// matched case-insensitively. The methods are ordered the way the ABI resolved
+// their names, i.e. foo before foo0 before foo1.
+func (abi *ABI) MethodByRawName(rawName string) []*Method {
+	var methods []*Method
+	for _, method := range abi.Methods {
+		if strings.EqualFold(method.RawName, rawName) {
+			method := method
+			methods = append(methods, &method)
+		}
+	}
+	sort.Slice(methods, func(i, j int) bool {
+		if len(methods[i].Name) != len(methods[j].Name) {
+			return len(methods[i].Name) < len(methods[j].Name)
+		}
+		return methods[i].Name < methods[j].Name
+	})
+	return methods
+}
+
 // EventByID looks an event up by its topic hash in the
 // ABI and returns nil if none found.
 func (abi *ABI) EventByID(topic common.Hash) (*Event, error) {
@@ -991,6 +991,93 @@ func TestABI_MethodById(t *testing.T) {
 	}
 }
 
+func TestABI_MethodByRawName(t *testing.T) {
+	json := `[
+		{"type":"function","name":"foo","inputs":[{"name":"a","type":"int256"},{"name":"b","type":"int256"}]},
+		{"type":"function","name":"foo","inputs":[{"name":"a","type":"uint256"},{"name":"b","type":"uint256"}]},
+		{"type":"function","name":"bar","inputs":[]}
+	]`
+	abi, err := JSON(strings.NewReader(json))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"foo(int256,int256)", "foo(uint256,uint256)"}
+	for _, name := range []string{"foo", "FOO", "Foo"} {
+		// Map iteration order is random, repeat to catch unstable ordering
+		for i := 0; i < 10; i++ {
+			methods := abi.MethodByRawName(name)
+			if len(methods) != len(want) {
+				t.Fatalf("%s: wrong number of overloads: have %d, want %d", name, len(methods), len(want))
+			}
+			for j, method := range methods {
+				if method.Sig != want[j] {
+					t.Errorf("%s: overload %d mismatch: have %s, want %s", name, j, method.Sig, want[j])
+				}
+			}
+		}
+	}
+	if methods := abi.MethodByRawName("baz"); len(methods) != 0 {
+		t.Errorf("expected no methods, have %d", len(methods))
+	}
+}
+
+func TestDecodeCall(t *testing.T) {
+	json := `[
+		{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]},
+		{"type":"function","name":"foo","inputs":[{"name":"a","type":"uint256"}]},
+		{"type":"function","name":"foo","inputs":[{"name":"a","type":"uint256"},{"name":"","type":"bool"}]}
+	]`
+	abi, err := JSON(strings.NewReader(json))
+	if err != nil {
+		t.Fatal(err)
+	}
+	to := common.HexToAddress("0x1111111111111111111111111111111111111111")
+
+	data, err := abi.Pack("transfer", to, big.NewInt(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	method, args, err := DecodeCall(&abi, data)
+	if err != nil {
+		t.Fatalf("failed to decode transfer: %v", err)
+	}
+	if method.Sig != "transfer(address,uint256)" {
+		t.Errorf("wrong method: have %s", method.Sig)
+	}
+	if args["to"] != to {
+		t.Errorf("wrong recipient: have %v, want %v", args["to"], to)
+	}
+	if value, ok := args["value"].(*big.Int); !ok || value.Cmp(big.NewInt(100)) != 0 {
+		t.Errorf("wrong value: have %v, want 100", args["value"])
+	}
+	if have, want := FormatCall(method, args), "transfer(to: "+to.Hex()+", value: 100)"; have != want {
+		t.Errorf("wrong summary: have %q, want %q", have, want)
+	}
+
+	// The overloaded method is resolved by its selector
+	data, err = abi.Pack("foo0", big.NewInt(7), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	method, args, err = DecodeCall(&abi, data)
+	if err != nil {
+		t.Fatalf("failed to decode overloaded call: %v", err)
+	}
+	if method.Sig != "foo(uint256,bool)" {
+		t.Errorf("wrong method: have %s", method.Sig)
+	}
+	if args["arg1"] != true {
+		t.Errorf("wrong unnamed argument: have %v, want true", args["arg1"])
+	}
+	if have, want := FormatCall(method, args), "foo(a: 7, arg1: true)"; have != want {
+		t.Errorf("wrong summary: have %q, want %q", have, want)
+	}
+
+	if _, _, err := DecodeCall(&abi, []byte{0xde, 0xad, 0xbe, 0xef}); err == nil {
+		t.Error("expected error for unknown selector")
+	}
+}
+
 func TestABI_EventById(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -151,6 +151,64 @@ func NewMethod(name string, rawName string, funType FunctionType, mutability str
 	}
 }
 
+// Validate checks that the named arguments of the method are unique within its
+// inputs and within its outputs, as keyed unpacking would otherwise lose values.
+func (method Method) Validate() error {
+	if err := checkUniqueNames(method.Inputs); err != nil {
+		return fmt.Errorf("abi: method %s: input %v", method.Name, err)
+	}
+	if err := checkUniqueNames(method.Outputs); err != nil {
+		return fmt.Errorf("abi: method %s: output %v", method.Name, err)
+	}
+	return nil
+}
+
+// ValidateABIMethods checks a set of methods for selector collisions, duplicate
+// resolved names and missing state mutability, reporting all problems found.
+func ValidateABIMethods(methods []Method) error {
+	var (
+		problems  []string
+		names     = make(map[string]bool)
+		selectors = make(map[string]string)
+	)
+	for _, method := range methods {
+		if names[method.Name] {
+			problems = append(problems, fmt.Sprintf("duplicate method name %q", method.Name))
+		}
+		names[method.Name] = true
+
+		if method.Type == Function {
+			if other, ok := selectors[string(method.ID)]; ok {
+				problems = append(problems, fmt.Sprintf("selector %#x of %s collides with %s", method.ID, method.Sig, other))
+			} else {
+				selectors[string(method.ID)] = method.Sig
+			}
+		}
+		if method.StateMutability == "" {
+			problems = append(problems, fmt.Sprintf("method %q is missing its state mutability", method.Name))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("abi: invalid methods: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// checkUniqueNames returns an error if a non-empty argument name is used twice.
+func checkUniqueNames(args Arguments) error {
+	seen := make(map[string]bool, len(args))
+	for _, arg := range args {
+		if arg.Name == "" {
+			continue
+		}
+		if seen[arg.Name] {
+			return fmt.Errorf("argument %q is declared more than once", arg.Name)
+		}
+		seen[arg.Name] = true
+	}
+	return nil
+}
+
 func (method Method) String() string {
 	return method.str
 }
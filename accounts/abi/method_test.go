@@ -17,6 +17,7 @@
 package abi
 
 import (
+	"bytes"
 	"strings"
 	"testing"
 )
@@ -143,3 +144,74 @@ func TestMethodSig(t *testing.T) {
 		}
 	}
 }
+
+func TestMethodValidate(t *testing.T) {
+	uint256, _ := NewType("uint256", "", nil)
+	args := func(names ...string) Arguments {
+		var arguments Arguments
+		for _, name := range names {
+			arguments = append(arguments, Argument{Name: name, Type: uint256})
+		}
+		return arguments
+	}
+	var cases = []struct {
+		inputs  Arguments
+		outputs Arguments
+		valid   bool
+	}{
+		{inputs: args("a", "b"), outputs: args("c", "d"), valid: true},
+		{inputs: args("a", "b"), outputs: args("a", "b"), valid: true},
+		{inputs: args("", ""), outputs: args("", ""), valid: true},
+		{inputs: args("a", "a"), outputs: nil, valid: false},
+		{inputs: nil, outputs: args("c", "", "c"), valid: false},
+	}
+	for i, test := range cases {
+		method := NewMethod("foo", "foo", Function, "", false, false, test.inputs, test.outputs)
+		err := method.Validate()
+		if test.valid && err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("test %d: expected duplicate name error", i)
+		}
+	}
+}
+
+func TestValidateABIMethods(t *testing.T) {
+	uint256, _ := NewType("uint256", "", nil)
+	bytes16, _ := NewType("bytes16", "", nil)
+	var (
+		burn    = NewMethod("burn", "burn", Function, "nonpayable", false, false, Arguments{{Name: "amount", Type: uint256}}, nil)
+		collate = NewMethod("collate_propagate_storage", "collate_propagate_storage", Function, "nonpayable", false, false, Arguments{{Name: "a", Type: bytes16}}, nil)
+		mint    = NewMethod("mint", "mint", Function, "nonpayable", false, false, Arguments{{Name: "amount", Type: uint256}}, nil)
+		legacy  = NewMethod("legacy", "legacy", Function, "", true, false, nil, nil)
+	)
+	if !bytes.Equal(burn.ID, collate.ID) {
+		t.Fatalf("test methods don't collide: %x != %x", burn.ID, collate.ID)
+	}
+
+	var cases = []struct {
+		methods []Method
+		valid   bool
+	}{
+		{methods: []Method{burn, mint}, valid: true},
+		{methods: []Method{burn, collate}, valid: false},
+		{methods: []Method{burn, burn}, valid: false},
+		{methods: []Method{mint, legacy}, valid: false},
+	}
+	for i, test := range cases {
+		err := ValidateABIMethods(test.methods)
+		if test.valid && err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("test %d: expected error", i)
+		}
+	}
+
+	// All problems are reported at once
+	err := ValidateABIMethods([]Method{burn, collate, legacy})
+	if err == nil || !strings.Contains(err.Error(), "collides") || !strings.Contains(err.Error(), "mutability") {
+		t.Errorf("expected collision and mutability problems, have %v", err)
+	}
+}
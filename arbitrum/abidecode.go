@@ -0,0 +1,107 @@
+package arbitrum
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/youngqqcn/arbitrum/accounts/abi"
+	"github.com/youngqqcn/arbitrum/common"
+	"github.com/youngqqcn/arbitrum/common/hexutil"
+	"github.com/youngqqcn/arbitrum/core"
+	"github.com/youngqqcn/arbitrum/core/vm"
+	"github.com/youngqqcn/arbitrum/crypto"
+)
+
+var (
+	errorSelector = crypto.Keccak256([]byte("Error(string)"))[:4]
+	panicSelector = crypto.Keccak256([]byte("Panic(uint256)"))[:4]
+)
+
+// RevertKind classifies the data a call reverted with
+type RevertKind string
+
+const (
+	RevertEmpty  RevertKind = "empty"  // reverted without data
+	RevertError  RevertKind = "error"  // Error(string), as raised by require and revert with a message
+	RevertPanic  RevertKind = "panic"  // Panic(uint256), as raised by failing asserts and arithmetic checks
+	RevertCustom RevertKind = "custom" // a custom error, or data not following any standard encoding
+)
+
+// Revert is the decoded data of a reverted call
+type Revert struct {
+	Kind      RevertKind     `json:"kind"`
+	Reason    string         `json:"reason,omitempty"`    // the message of an Error(string)
+	PanicCode *hexutil.Big   `json:"panicCode,omitempty"` // the code of a Panic(uint256)
+	Selector  *hexutil.Bytes `json:"selector,omitempty"`  // the selector of a custom error, if the data is long enough to hold one
+	Data      hexutil.Bytes  `json:"data"`
+}
+
+// DecodeRevert decodes the data a call reverted with, recognizing the standard Error(string) and Panic(uint256) encodings.
+// Data claiming a standard selector but failing to decode is reported as a custom error.
+func DecodeRevert(data []byte) *Revert {
+	revert := &Revert{Kind: RevertCustom, Data: common.CopyBytes(data)}
+	if len(data) == 0 {
+		revert.Kind = RevertEmpty
+		return revert
+	}
+	if len(data) < 4 {
+		return revert
+	}
+	switch {
+	case bytes.Equal(data[:4], errorSelector):
+		if reason, err := abi.UnpackRevert(data); err == nil {
+			revert.Kind = RevertError
+			revert.Reason = reason
+			return revert
+		}
+	case bytes.Equal(data[:4], panicSelector):
+		if len(data) == 4+32 {
+			revert.Kind = RevertPanic
+			revert.PanicCode = (*hexutil.Big)(new(big.Int).SetBytes(data[4:]))
+			return revert
+		}
+	}
+	selector := hexutil.Bytes(common.CopyBytes(data[:4]))
+	revert.Selector = &selector
+	return revert
+}
+
+// DecodeRevertResult decodes the revert data of an execution result, or returns nil if the execution didn't revert.
+// Unlike NewRevertReason, the payload is returned decoded rather than as an error message.
+func DecodeRevertResult(result *core.ExecutionResult) *Revert {
+	if result == nil || result.Err != vm.ErrExecutionReverted {
+		return nil
+	}
+	return DecodeRevert(result.Revert())
+}
+
+// DecodeTransactionInput decodes the calldata of a transaction against the given JSON ABI,
+// returning the name of the called method and its arguments, keyed as by abi.DecodeCall
+func (a *APIBackend) DecodeTransactionInput(ctx context.Context, txHash common.Hash, abiJSON string) (string, map[string]interface{}, error) {
+	tx, _, _, _, err := a.GetTransaction(ctx, txHash)
+	if err != nil {
+		return "", nil, err
+	}
+	if tx == nil {
+		return "", nil, fmt.Errorf("transaction %v not found", txHash)
+	}
+	parsed, err := abi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid ABI: %w", err)
+	}
+	data := tx.Data()
+	if len(data) < 4 {
+		return "", nil, fmt.Errorf("transaction input too short for a method selector: %d bytes", len(data))
+	}
+	if _, err := parsed.MethodById(data); err != nil {
+		return "", nil, fmt.Errorf("selector %#x doesn't match any method of the ABI", data[:4])
+	}
+	method, args, err := abi.DecodeCall(&parsed, data)
+	if err != nil {
+		return "", nil, err
+	}
+	return method.Name, args, nil
+}
@@ -0,0 +1,109 @@
+package arbitrum
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/youngqqcn/arbitrum/accounts/abi"
+	"github.com/youngqqcn/arbitrum/common"
+	"github.com/youngqqcn/arbitrum/core"
+	"github.com/youngqqcn/arbitrum/core/types"
+	"github.com/youngqqcn/arbitrum/core/vm"
+	"github.com/youngqqcn/arbitrum/crypto"
+	"github.com/youngqqcn/arbitrum/params"
+)
+
+const testTransferABI = `[{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]}]`
+
+func TestDecodeTransactionInput(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(testTransferABI))
+	if err != nil {
+		t.Fatalf("failed to parse ABI: %v", err)
+	}
+	to, value := common.Address{0xbb}, big.NewInt(1234)
+	input, err := parsed.Pack("transfer", to, value)
+	if err != nil {
+		t.Fatalf("failed to pack call: %v", err)
+	}
+	signer := types.LatestSigner(params.ArbitrumDevTestChainConfig())
+	var txs []*types.Transaction
+	api, _ := newTestAPIBackendWithAlloc(t, testEmitterAlloc, 1, func(i int, b *core.BlockGen) {
+		for nonce, data := range [][]byte{input, {0xde, 0xad, 0xbe, 0xef}} {
+			tx, err := types.SignTx(types.NewTransaction(uint64(nonce), testEmitter, common.Big0, 100000, b.BaseFee(), data), signer, testKey)
+			if err != nil {
+				t.Fatalf("failed to sign tx: %v", err)
+			}
+			b.AddTx(tx)
+			txs = append(txs, tx)
+		}
+	})
+
+	method, args, err := api.DecodeTransactionInput(context.Background(), txs[0].Hash(), testTransferABI)
+	if err != nil {
+		t.Fatalf("failed to decode input: %v", err)
+	}
+	if method != "transfer" {
+		t.Errorf("wrong method: have %q, want %q", method, "transfer")
+	}
+	if have, ok := args["to"].(common.Address); !ok || have != to {
+		t.Errorf("wrong to argument: have %v, want %v", args["to"], to)
+	}
+	if have, ok := args["value"].(*big.Int); !ok || have.Cmp(value) != 0 {
+		t.Errorf("wrong value argument: have %v, want %v", args["value"], value)
+	}
+
+	if _, _, err := api.DecodeTransactionInput(context.Background(), txs[1].Hash(), testTransferABI); err == nil || !strings.Contains(err.Error(), "doesn't match any method") {
+		t.Errorf("wrong error for unknown selector: %v", err)
+	}
+	if _, _, err := api.DecodeTransactionInput(context.Background(), common.Hash{0x01}, testTransferABI); err == nil {
+		t.Error("expected error for unknown transaction")
+	}
+}
+
+func TestDecodeRevert(t *testing.T) {
+	stringType, _ := abi.NewType("string", "", nil)
+	reason, err := abi.Arguments{{Type: stringType}}.Pack("insufficient balance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	errorData := append(crypto.Keccak256([]byte("Error(string)"))[:4], reason...)
+	panicData := append(crypto.Keccak256([]byte("Panic(uint256)"))[:4], common.LeftPadBytes([]byte{0x11}, 32)...)
+	customSelector := crypto.Keccak256([]byte("Unauthorized(address)"))[:4]
+	customData := append(common.CopyBytes(customSelector), common.LeftPadBytes(testAddr.Bytes(), 32)...)
+
+	revert := DecodeRevert(errorData)
+	if revert.Kind != RevertError || revert.Reason != "insufficient balance" {
+		t.Errorf("wrong string revert: %+v", revert)
+	}
+	revert = DecodeRevert(panicData)
+	if revert.Kind != RevertPanic || revert.PanicCode.ToInt().Uint64() != 0x11 {
+		t.Errorf("wrong panic revert: %+v", revert)
+	}
+	revert = DecodeRevert(customData)
+	if revert.Kind != RevertCustom || revert.Selector == nil || !bytes.Equal(*revert.Selector, customSelector) || !bytes.Equal(revert.Data, customData) {
+		t.Errorf("wrong custom revert: %+v", revert)
+	}
+	revert = DecodeRevert(nil)
+	if revert.Kind != RevertEmpty || revert.Selector != nil || len(revert.Data) != 0 {
+		t.Errorf("wrong empty revert: %+v", revert)
+	}
+	// A standard selector with a malformed payload is reported as is
+	revert = DecodeRevert(errorData[:10])
+	if revert.Kind != RevertCustom || revert.Reason != "" {
+		t.Errorf("wrong malformed revert: %+v", revert)
+	}
+
+	result := &core.ExecutionResult{Err: vm.ErrExecutionReverted, ReturnData: panicData}
+	if revert := DecodeRevertResult(result); revert == nil || revert.Kind != RevertPanic {
+		t.Errorf("wrong revert of execution result: %+v", revert)
+	}
+	if revert := DecodeRevertResult(&core.ExecutionResult{Err: vm.ErrOutOfGas}); revert != nil {
+		t.Errorf("got revert for a call running out of gas: %+v", revert)
+	}
+	if revert := DecodeRevertResult(&core.ExecutionResult{ReturnData: errorData}); revert != nil {
+		t.Errorf("got revert for a successful call: %+v", revert)
+	}
+}
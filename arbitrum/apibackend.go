@@ -7,6 +7,7 @@ import (
 	"math/big"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	ethereum "github.com/youngqqcn/arbitrum"
@@ -17,6 +18,7 @@ import (
 
 	"github.com/youngqqcn/arbitrum/accounts"
 	"github.com/youngqqcn/arbitrum/common"
+	"github.com/youngqqcn/arbitrum/common/lru"
 	"github.com/youngqqcn/arbitrum/consensus"
 	"github.com/youngqqcn/arbitrum/core"
 	"github.com/youngqqcn/arbitrum/core/bloombits"
@@ -32,11 +34,28 @@ import (
 	"github.com/youngqqcn/arbitrum/rpc"
 )
 
+var (
+	// ErrBlockNotYetAvailable is returned for the safe and finalized block tags before the node knows those blocks
+	ErrBlockNotYetAvailable = errors.New("block not yet available")
+
+	// ErrStateTooOld is returned when recreating the state of a block would take more than the configured rewind
+	ErrStateTooOld = errors.New("state too old, use an archive node or the classic fallback")
+)
+
 type APIBackend struct {
 	b *Backend
 
 	fallbackClient types.FallbackClient
-	sync           SyncProgressBackend
+
+	syncMutex sync.RWMutex
+	sync      SyncProgressBackend
+
+	feeHistoryCache *lru.Cache[feeHistoryCacheKey, *feeHistoryCacheEntry] // nil when disabled
+
+	classicMisses *lru.Cache[common.Hash, struct{}] // hashes the classic node doesn't know
+
+	pendingMutex sync.Mutex
+	pending      *pendingBlock // the last pending block prediction
 }
 
 type timeoutFallbackClient struct {
@@ -65,19 +84,27 @@ func CreateFallbackClient(fallbackClientUrl string, fallbackClientTimeout time.D
 		types.SetFallbackError(strings.Join(fields, ":"), int(errNumber))
 		return nil, nil
 	}
-	var fallbackClient types.FallbackClient
-	var err error
-	fallbackClient, err = rpc.Dial(fallbackClientUrl)
-	if fallbackClient == nil || err != nil {
-		return nil, fmt.Errorf("failed creating fallback connection: %w", err)
-	}
-	if fallbackClientTimeout != 0 {
-		fallbackClient = &timeoutFallbackClient{
-			impl:    fallbackClient,
-			timeout: fallbackClientTimeout,
+	// several comma separated urls are load balanced, failing over between them
+	var endpoints []types.FallbackClient
+	for _, url := range strings.Split(fallbackClientUrl, ",") {
+		var fallbackClient types.FallbackClient
+		var err error
+		fallbackClient, err = rpc.Dial(strings.TrimSpace(url))
+		if fallbackClient == nil || err != nil {
+			return nil, fmt.Errorf("failed creating fallback connection: %w", err)
+		}
+		if fallbackClientTimeout != 0 {
+			fallbackClient = &timeoutFallbackClient{
+				impl:    fallbackClient,
+				timeout: fallbackClientTimeout,
+			}
 		}
+		endpoints = append(endpoints, fallbackClient)
 	}
-	return fallbackClient, nil
+	if len(endpoints) == 1 {
+		return &requestIDFallbackClient{endpoints[0]}, nil
+	}
+	return &requestIDFallbackClient{newRoundRobinFallbackClient(endpoints)}, nil
 }
 
 type SyncProgressBackend interface {
@@ -86,16 +113,47 @@ type SyncProgressBackend interface {
 	FinalizedBlockNumber(ctx context.Context) (uint64, error)
 }
 
+// SyncTargetBackend can optionally be implemented by a SyncProgressBackend to report
+// the block the sync started at and the head it's syncing towards
+type SyncTargetBackend interface {
+	SyncTarget() (startingBlock uint64, highestBlock uint64)
+}
+
+// SetSyncProgressBackend replaces the source of the sync progress and the safe and finalized block numbers.
+// It's safe to call while the backend is serving requests.
+func (a *APIBackend) SetSyncProgressBackend(sync SyncProgressBackend) error {
+	if sync == nil {
+		return errors.New("sync progress backend must not be nil")
+	}
+	a.syncMutex.Lock()
+	defer a.syncMutex.Unlock()
+	a.sync = sync
+	return nil
+}
+
+func (a *APIBackend) syncProgressBackend() SyncProgressBackend {
+	a.syncMutex.RLock()
+	defer a.syncMutex.RUnlock()
+	return a.sync
+}
+
 func createRegisterAPIBackend(backend *Backend, sync SyncProgressBackend, filterConfig filters.Config, fallbackClientUrl string, fallbackClientTimeout time.Duration) (*filters.FilterSystem, error) {
 	fallbackClient, err := CreateFallbackClient(fallbackClientUrl, fallbackClientTimeout)
 	if err != nil {
 		return nil, err
 	}
+	fallbackClient = newRetryingFallbackClient(fallbackClient, backend.config.FallbackClientRetries, backend.config.FallbackClientRetryDelay)
+	if backend.config.FallbackClientMetrics {
+		fallbackClient = newMeteredFallbackClient(fallbackClient)
+	}
 	backend.apiBackend = &APIBackend{
-		b:              backend,
-		fallbackClient: fallbackClient,
-		sync:           sync,
+		b:               backend,
+		fallbackClient:  fallbackClient,
+		sync:            sync,
+		feeHistoryCache: newFeeHistoryCache(backend.config.FeeHistoryCacheSize),
+		classicMisses:   lru.NewCache[common.Hash, struct{}](classicMissesCacheSize),
 	}
+	filterConfig.RangeLimit = backend.config.MaxFilterRange
 	filterSystem := filters.NewFilterSystem(backend.apiBackend, filterConfig)
 	backend.stack.RegisterAPIs(backend.apiBackend.GetAPIs(filterSystem))
 	return filterSystem, nil
@@ -104,10 +162,11 @@ func createRegisterAPIBackend(backend *Backend, sync SyncProgressBackend, filter
 func (a *APIBackend) GetAPIs(filterSystem *filters.FilterSystem) []rpc.API {
 	apis := ethapi.GetAPIs(a)
 
+	filterAPI := filters.NewFilterAPI(filterSystem, false)
 	apis = append(apis, rpc.API{
 		Namespace: "eth",
 		Version:   "1.0",
-		Service:   filters.NewFilterAPI(filterSystem, false),
+		Service:   filterAPI,
 		Public:    true,
 	})
 
@@ -118,6 +177,13 @@ func (a *APIBackend) GetAPIs(filterSystem *filters.FilterSystem) []rpc.API {
 		Public:    true,
 	})
 
+	apis = append(apis, rpc.API{
+		Namespace: "arb",
+		Version:   "1.0",
+		Service:   NewArbAPI(a),
+		Public:    true,
+	})
+
 	apis = append(apis, rpc.API{
 		Namespace: "net",
 		Version:   "1.0",
@@ -134,7 +200,28 @@ func (a *APIBackend) GetAPIs(filterSystem *filters.FilterSystem) []rpc.API {
 
 	apis = append(apis, tracers.APIs(a)...)
 
-	return apis
+	if a.b.config.ExpensiveMethodRateLimits.Requests > 0 {
+		apis = append(apis, a.rateLimitedAPIs(filterAPI)...)
+	}
+
+	return filterNamespaces(apis, a.b.config.EnabledNamespaces)
+}
+
+// filterNamespaces returns the APIs of the enabled namespaces, or all of them if none are listed
+func filterNamespaces(apis []rpc.API, enabled []string) []rpc.API {
+	if len(enabled) == 0 {
+		return apis
+	}
+	var filtered []rpc.API
+	for _, api := range apis {
+		for _, namespace := range enabled {
+			if api.Namespace == namespace {
+				filtered = append(filtered, api)
+				break
+			}
+		}
+	}
+	return filtered
 }
 
 func (a *APIBackend) blockChain() *core.BlockChain {
@@ -149,28 +236,60 @@ func (a *APIBackend) GetBody(ctx context.Context, hash common.Hash, number rpc.B
 	if body := a.blockChain().GetBody(hash); body != nil {
 		return body, nil
 	}
+	if a.fallbackClient != nil && (number < 0 || !a.ChainConfig().IsArbitrumNitro(big.NewInt(int64(number)))) {
+		return a.classicBody(ctx, hash)
+	}
 	return nil, errors.New("block body not found")
 }
 
+// classicBlock is the part of a classic node's eth_getBlockByHash response making up the block body
+type classicBlock struct {
+	Transactions []*types.Transaction `json:"transactions"`
+}
+
+// classicBody fetches the body of a pre-Nitro block from the classic node
+func (a *APIBackend) classicBody(ctx context.Context, hash common.Hash) (*types.Body, error) {
+	var block *classicBlock
+	if err := a.fallbackClient.CallContext(ctx, &block, "eth_getBlockByHash", hash, true); err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, errors.New("block body not found")
+	}
+	// Classic blocks have no uncles
+	return &types.Body{Transactions: block.Transactions}, nil
+}
+
 // General Ethereum API
 func (a *APIBackend) SyncProgressMap() map[string]interface{} {
-	return a.sync.SyncProgressMap()
+	return a.syncProgressBackend().SyncProgressMap()
 }
 
 func (a *APIBackend) SyncProgress() ethereum.SyncProgress {
-	progress := a.sync.SyncProgressMap()
+	progress := a.syncProgressBackend().SyncProgressMap()
 
 	if progress == nil || len(progress) == 0 {
 		return ethereum.SyncProgress{}
 	}
+	current := a.blockChain().CurrentBlock().NumberU64()
+	var starting, highest uint64
+	if target, ok := a.syncProgressBackend().(SyncTargetBackend); ok {
+		starting, highest = target.SyncTarget()
+	}
+	if highest <= current {
+		// still syncing according to the progress map, so the target must be ahead of us
+		highest = current + 1
+	}
 	return ethereum.SyncProgress{
-		CurrentBlock: 0,
-		HighestBlock: 1,
+		StartingBlock: starting,
+		CurrentBlock:  current,
+		HighestBlock:  highest,
 	}
 }
 
 func (a *APIBackend) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
-	return big.NewInt(0), nil // there's no tips in L2
+	// there's no tips in L2, but some wallets refuse to send transactions without one
+	return new(big.Int).SetUint64(a.b.config.MinGasTip), nil
 }
 
 func (a *APIBackend) FeeHistory(
@@ -187,38 +306,33 @@ func (a *APIBackend) FeeHistory(
 	nitroGenesis := rpc.BlockNumber(a.ChainConfig().ArbitrumChainParams.GenesisBlockNum)
 	newestBlock, latestBlock := a.blockChain().ClipToPostNitroGenesis(newestBlock)
 
+	log.Debug("Serving fee history", withRequestID(ctx, "blocks", blocks, "newest", newestBlock)...)
+
+	// don't attempt to include blocks before genesis, which on a young chain
+	// keeps a large request from being sized by the max block count
+	if available := int(newestBlock - nitroGenesis + 1); blocks > available {
+		blocks = available
+	}
 	maxFeeHistory := int(a.b.config.FeeHistoryMaxBlockCount)
 	if blocks > maxFeeHistory {
-		log.Warn("Sanitizing fee history length", "requested", blocks, "truncated", maxFeeHistory)
+		log.Warn("Sanitizing fee history length", withRequestID(ctx, "requested", blocks, "truncated", maxFeeHistory)...)
 		blocks = maxFeeHistory
 	}
 	if blocks < 1 {
 		// returning with no data and no error means there are no retrievable blocks
 		return common.Big0, nil, nil, nil, nil
 	}
-
-	// don't attempt to include blocks before genesis
-	if rpc.BlockNumber(blocks) > (newestBlock - nitroGenesis) {
-		blocks = int(newestBlock - nitroGenesis + 1)
-	}
 	oldestBlock := int(newestBlock) + 1 - blocks
 
-	// inform that tipping has no effect on inclusion
-	rewards := make([][]*big.Int, blocks)
-	zeros := make([]*big.Int, len(rewardPercentiles))
-	for i := range zeros {
-		zeros[i] = common.Big0
-	}
-	for i := range rewards {
-		rewards[i] = zeros
-	}
-	if len(rewardPercentiles) == 0 {
-		rewards = nil
+	for i, p := range rewardPercentiles {
+		if p < 0 || p > 100 || (i > 0 && p < rewardPercentiles[i-1]) {
+			return common.Big0, nil, nil, nil, fmt.Errorf("invalid reward percentile: #%d %f", i, p)
+		}
 	}
 
 	// use the most recent average compute rate for all blocks
 	// note: while we could query this value for each block, it'd be prohibitively expensive
-	state, _, err := a.StateAndHeaderByNumber(ctx, rpc.BlockNumber(newestBlock))
+	state, newestHeader, err := a.StateAndHeaderByNumber(ctx, rpc.BlockNumber(newestBlock))
 	if err != nil {
 		return common.Big0, nil, nil, nil, err
 	}
@@ -227,6 +341,66 @@ func (a *APIBackend) FeeHistory(
 		return common.Big0, nil, nil, nil, err
 	}
 
+	var gasUsed []float64
+	var basefees []*big.Int
+	cacheKey := feeHistoryCacheKey{oldestBlock: uint64(oldestBlock), newestHash: newestHeader.Hash(), speedLimit: speedLimit}
+	// the latest block's predicted basefee changes with the next block, so windows including it aren't cached
+	cacheable := a.feeHistoryCache != nil && newestBlock != latestBlock
+	var cached *feeHistoryCacheEntry
+	if cacheable {
+		cached, _ = a.feeHistoryCache.Get(cacheKey)
+	}
+	var tips []*blockTips
+	if cached != nil {
+		gasUsed = append([]float64{}, cached.gasUsed...)
+		basefees = append([]*big.Int{}, cached.basefees...)
+		tips = cached.tips
+	} else {
+		gasUsed, basefees, err = a.feeHistoryBlocks(ctx, oldestBlock, newestBlock, latestBlock, nitroGenesis, speedLimit)
+		if err != nil {
+			return common.Big0, nil, nil, nil, err
+		}
+	}
+	// tipping has no effect on inclusion, but report the tips actually paid for fee estimators relying on them
+	var rewards [][]*big.Int
+	if len(rewardPercentiles) > 0 || a.b.config.FeeHistoryEmptyRewards {
+		rewards = make([][]*big.Int, blocks)
+		if len(rewardPercentiles) > 0 && tips == nil {
+			tips = make([]*blockTips, blocks)
+			for i := range tips {
+				tips[i], err = a.blockTips(ctx, rpc.BlockNumber(oldestBlock+i))
+				if err != nil {
+					return common.Big0, nil, nil, nil, err
+				}
+			}
+		}
+		for i := range rewards {
+			if len(rewardPercentiles) == 0 {
+				rewards[i] = []*big.Int{}
+			} else {
+				rewards[i] = tips[i].rewards(rewardPercentiles)
+			}
+		}
+	}
+	// the tips are cached with the window the first time rewards are requested for it
+	if cacheable && (cached == nil || (cached.tips == nil && tips != nil)) {
+		a.feeHistoryCache.Add(cacheKey, &feeHistoryCacheEntry{
+			gasUsed:  append([]float64{}, gasUsed...),
+			basefees: append([]*big.Int{}, basefees...),
+			tips:     tips,
+		})
+	}
+	if a.b.config.FeeHistoryOmitProjected {
+		basefees = basefees[:blocks]
+	}
+
+	return big.NewInt(int64(oldestBlock)), rewards, basefees, gasUsed, nil
+}
+
+// feeHistoryBlocks computes the fullness analogues of the blocks in [oldestBlock, newestBlock], and their basefees
+// followed by the basefee predicted for the block after newestBlock
+func (a *APIBackend) feeHistoryBlocks(ctx context.Context, oldestBlock int, newestBlock, latestBlock, nitroGenesis rpc.BlockNumber, speedLimit uint64) ([]float64, []*big.Int, error) {
+	blocks := int(newestBlock) + 1 - oldestBlock
 	gasUsed := make([]float64, blocks)
 	basefees := make([]*big.Int, blocks+1) // the RPC semantics are to predict the future value
 
@@ -241,14 +415,14 @@ func (a *APIBackend) FeeHistory(
 	if rpc.BlockNumber(oldestBlock) > nitroGenesis {
 		header, err := a.HeaderByNumber(ctx, rpc.BlockNumber(oldestBlock-1))
 		if err != nil {
-			return common.Big0, nil, nil, nil, err
+			return nil, nil, err
 		}
 		prevTimestamp = header.Time
 	}
 	for block := oldestBlock; block <= int(baseFeeLookup); block++ {
 		header, err := a.HeaderByNumber(ctx, rpc.BlockNumber(block))
 		if err != nil {
-			return common.Big0, nil, nil, nil, err
+			return nil, nil, err
 		}
 		basefees[block-oldestBlock] = header.BaseFee
 
@@ -289,8 +463,7 @@ func (a *APIBackend) FeeHistory(
 	if newestBlock == latestBlock {
 		basefees[blocks] = basefees[blocks-1] // guess the basefee won't change
 	}
-
-	return big.NewInt(int64(oldestBlock)), rewards, basefees, gasUsed, nil
+	return gasUsed, basefees, nil
 }
 
 func (a *APIBackend) ChainDb() ethdb.Database {
@@ -302,7 +475,7 @@ func (a *APIBackend) AccountManager() *accounts.Manager {
 }
 
 func (a *APIBackend) ExtRPCEnabled() bool {
-	panic("not implemented") // TODO: Implement
+	return a.b.stack.Config().ExtRPCEnabled()
 }
 
 func (a *APIBackend) RPCGasCap() uint64 {
@@ -322,8 +495,30 @@ func (a *APIBackend) UnprotectedAllowed() bool {
 }
 
 // Blockchain API
+// SetHead rewinds the chain to the given block, e.g. to recover from a bad import. As it can't report errors,
+// refusals are logged: it requires dangerous debug calls and paused block production, and won't rewind below
+// the Nitro genesis block.
 func (a *APIBackend) SetHead(number uint64) {
-	panic("not implemented") // TODO: Implement
+	if !a.b.config.AllowDangerousDebugCalls {
+		log.Warn("Refusing to set head", "number", number, "err", errDangerousDebugCallsDisabled)
+		return
+	}
+	if !a.b.BlockProductionPaused() {
+		log.Warn("Refusing to set head while block production is running", "number", number)
+		return
+	}
+	if genesis := a.ChainConfig().ArbitrumChainParams.GenesisBlockNum; number < genesis {
+		log.Warn("Refusing to set head below the Nitro genesis block", "number", number, "genesis", genesis)
+		return
+	}
+	if head := a.CurrentBlock().NumberU64(); number >= head {
+		return
+	}
+	if err := a.blockChain().SetHead(number); err != nil {
+		log.Error("Failed to set head", "number", number, "err", err)
+		return
+	}
+	log.Warn("Rewound chain head", "number", number)
 }
 
 func (a *APIBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
@@ -331,18 +526,62 @@ func (a *APIBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber)
 }
 
 func (a *APIBackend) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
-	return a.blockChain().GetHeaderByHash(hash), nil
+	if header := a.blockChain().GetHeaderByHash(hash); header != nil {
+		return header, nil
+	}
+	if a.fallbackClient != nil && a.ChainConfig().ArbitrumChainParams.GenesisBlockNum > 0 {
+		return a.classicHeader(ctx, hash)
+	}
+	return nil, errors.New("header not found")
+}
+
+// classicHeader fetches the header of a pre-Nitro block from the classic node.
+// Hashes the classic node doesn't know are remembered, so repeated lookups of unknown hashes stay local.
+func (a *APIBackend) classicHeader(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	if a.classicMisses != nil && a.classicMisses.Contains(hash) {
+		return nil, errors.New("header not found")
+	}
+	var header *types.Header
+	if err := a.fallbackClient.CallContext(ctx, &header, "eth_getBlockByHash", hash, false); err != nil {
+		return nil, err
+	}
+	if header == nil || a.ChainConfig().IsArbitrumNitro(header.Number) {
+		if a.classicMisses != nil {
+			a.classicMisses.Add(hash, struct{}{})
+		}
+		return nil, errors.New("header not found")
+	}
+	return header, nil
+}
+
+// CanonicalHash returns the hash of the canonical block at the given height, without reading its header
+func (a *APIBackend) CanonicalHash(ctx context.Context, number uint64) (common.Hash, error) {
+	hash := a.blockChain().GetCanonicalHash(number)
+	if hash == (common.Hash{}) {
+		return common.Hash{}, fmt.Errorf("block %d not found", number)
+	}
+	return hash, nil
 }
 
 func (a *APIBackend) blockNumberToUint(ctx context.Context, number rpc.BlockNumber) (uint64, error) {
 	if number == rpc.LatestBlockNumber || number == rpc.PendingBlockNumber {
 		return a.blockChain().CurrentBlock().Number().Uint64(), nil
 	}
-	if number == rpc.SafeBlockNumber {
-		return a.sync.SafeBlockNumber(ctx)
-	}
-	if number == rpc.FinalizedBlockNumber {
-		return a.sync.FinalizedBlockNumber(ctx)
+	if number == rpc.SafeBlockNumber || number == rpc.FinalizedBlockNumber {
+		var blockNum uint64
+		var err error
+		if number == rpc.SafeBlockNumber {
+			blockNum, err = a.syncProgressBackend().SafeBlockNumber(ctx)
+		} else {
+			blockNum, err = a.syncProgressBackend().FinalizedBlockNumber(ctx)
+		}
+		if err != nil {
+			if a.b.config.SafeFinalizedDefaultToGenesis {
+				return a.ChainConfig().ArbitrumChainParams.GenesisBlockNum, nil
+			}
+			return 0, fmt.Errorf("%w: %v", ErrBlockNotYetAvailable, err)
+		}
+		return blockNum, nil
 	}
 	if number < 0 {
 		return 0, errors.New("block number not supported")
@@ -361,7 +600,28 @@ func (a *APIBackend) headerByNumberImpl(ctx context.Context, number rpc.BlockNum
 	return a.blockChain().GetHeaderByNumber(numUint), nil
 }
 
+// defaultBlockNumber returns the block the configured default block parameter refers to
+func (a *APIBackend) defaultBlockNumber() (rpc.BlockNumber, error) {
+	return a.b.config.defaultBlockNumber()
+}
+
+// withDefaultBlock resolves an unspecified block reference, with neither number nor hash set, to the default block parameter
+func (a *APIBackend) withDefaultBlock(blockNrOrHash rpc.BlockNumberOrHash) (rpc.BlockNumberOrHash, error) {
+	if blockNrOrHash.BlockNumber != nil || blockNrOrHash.BlockHash != nil {
+		return blockNrOrHash, nil
+	}
+	number, err := a.defaultBlockNumber()
+	if err != nil {
+		return blockNrOrHash, err
+	}
+	return rpc.BlockNumberOrHashWithNumber(number), nil
+}
+
 func (a *APIBackend) headerByNumberOrHashImpl(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.Header, error) {
+	blockNrOrHash, err := a.withDefaultBlock(blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
 	number, isnum := blockNrOrHash.Number()
 	if isnum {
 		return a.headerByNumberImpl(ctx, number)
@@ -401,6 +661,10 @@ func (a *APIBackend) BlockByHash(ctx context.Context, hash common.Hash) (*types.
 }
 
 func (a *APIBackend) BlockByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.Block, error) {
+	blockNrOrHash, err := a.withDefaultBlock(blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
 	number, isnum := blockNrOrHash.Number()
 	if isnum {
 		return a.BlockByNumber(ctx, number)
@@ -412,6 +676,33 @@ func (a *APIBackend) BlockByNumberOrHash(ctx context.Context, blockNrOrHash rpc.
 	return nil, errors.New("invalid arguments; neither block nor hash specified")
 }
 
+// BlockMeta is a block's header along with metadata computed from the stored block
+type BlockMeta struct {
+	Header *types.Header
+	Size   uint64 // size of the RLP encoded block
+}
+
+// BlockByNumberOrHashWithMeta returns a block's header and its encoded size
+func (a *APIBackend) BlockByNumberOrHashWithMeta(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*BlockMeta, error) {
+	block, err := a.BlockByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, errors.New("block not found")
+	}
+	return &BlockMeta{Header: block.Header(), Size: block.Size()}, nil
+}
+
+// HeaderAndSizeByNumberOrHash is like HeaderByNumberOrHash, also returning the block's encoded size
+func (a *APIBackend) HeaderAndSizeByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.Header, uint64, error) {
+	meta, err := a.BlockByNumberOrHashWithMeta(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, 0, err
+	}
+	return meta.Header, meta.Size, nil
+}
+
 func (a *APIBackend) stateAndHeaderFromHeader(header *types.Header, err error) (*state.StateDB, *types.Header, error) {
 	if err != nil {
 		return nil, header, err
@@ -438,10 +729,33 @@ func (a *APIBackend) StateAtBlock(ctx context.Context, block *types.Block, reexe
 	if !a.blockChain().Config().IsArbitrumNitro(block.Number()) {
 		return nil, nil, types.ErrUseFallback
 	}
+	if maxRewind := a.b.config.StateAtBlockMaxRewind; maxRewind > 0 && base == nil {
+		if !a.stateWithinRewind(block, maxRewind) {
+			return nil, nil, fmt.Errorf("%w: no state within %d blocks of block %d", ErrStateTooOld, maxRewind, block.NumberU64())
+		}
+		if reexec > maxRewind {
+			reexec = maxRewind
+		}
+	}
 	// DEV: This assumes that `StateAtBlock` only accesses the blockchain and chainDb fields
 	return eth.NewArbEthereum(a.b.arb.BlockChain(), a.ChainDb()).StateAtBlock(ctx, block, reexec, base, checkLive, preferDisk)
 }
 
+// stateWithinRewind reports whether the state of the block, or of one of its closest maxRewind ancestors, is available
+func (a *APIBackend) stateWithinRewind(block *types.Block, maxRewind uint64) bool {
+	header := block.Header()
+	for i := uint64(0); header != nil && i <= maxRewind; i++ {
+		if a.blockChain().HasState(header.Root) {
+			return true
+		}
+		if header.Number.Sign() == 0 {
+			break
+		}
+		header = a.blockChain().GetHeader(header.ParentHash, header.Number.Uint64()-1)
+	}
+	return false
+}
+
 func (a *APIBackend) StateAtTransaction(ctx context.Context, block *types.Block, txIndex int, reexec uint64) (core.Message, vm.BlockContext, *state.StateDB, tracers.StateReleaseFunc, error) {
 	if !a.blockChain().Config().IsArbitrumNitro(block.Number()) {
 		return nil, vm.BlockContext{}, nil, nil, types.ErrUseFallback
@@ -455,6 +769,9 @@ func (a *APIBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.R
 }
 
 func (a *APIBackend) GetTd(ctx context.Context, hash common.Hash) *big.Int {
+	if !a.b.config.ReportTotalDifficulty {
+		return nil
+	}
 	if header := a.blockChain().GetHeaderByHash(hash); header != nil {
 		return a.blockChain().GetTd(hash, header.Number.Uint64())
 	}
@@ -463,14 +780,31 @@ func (a *APIBackend) GetTd(ctx context.Context, hash common.Hash) *big.Int {
 
 func (a *APIBackend) GetEVM(ctx context.Context, msg core.Message, state *state.StateDB, header *types.Header, vmConfig *vm.Config) (*vm.EVM, func() error, error) {
 	vmError := func() error { return nil }
-	if vmConfig == nil {
-		vmConfig = a.blockChain().GetVMConfig()
-	}
+	vmConfig = mergeVMConfig(a.blockChain().GetVMConfig(), vmConfig)
+	log.Trace("Creating EVM", withRequestID(ctx, "number", header.Number, "hash", header.Hash())...)
 	txContext := core.NewEVMTxContext(msg)
 	context := core.NewEVMBlockContext(header, a.blockChain(), nil)
 	return vm.NewEVM(context, txContext, state, a.blockChain().Config(), *vmConfig), vmError, nil
 }
 
+// mergeVMConfig fills the settings a caller's vm config leaves unset from the blockchain's default one.
+// Tracing and the base fee are per-call settings and always come from the caller, so the chain's tracer
+// never ends up attached to a plain eth_call. Preimage recording is only ever added, and the default
+// extra EIPs apply unless the caller lists its own.
+func mergeVMConfig(defaults *vm.Config, overrides *vm.Config) *vm.Config {
+	if overrides == nil {
+		merged := *defaults
+		merged.ExtraEips = append([]int{}, defaults.ExtraEips...)
+		return &merged
+	}
+	merged := *overrides
+	merged.EnablePreimageRecording = overrides.EnablePreimageRecording || defaults.EnablePreimageRecording
+	if overrides.ExtraEips == nil {
+		merged.ExtraEips = append([]int{}, defaults.ExtraEips...)
+	}
+	return &merged
+}
+
 func (a *APIBackend) SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription {
 	return a.blockChain().SubscribeChainEvent(ch)
 }
@@ -489,6 +823,14 @@ func (a *APIBackend) SendTx(ctx context.Context, signedTx *types.Transaction) er
 }
 
 func (a *APIBackend) SendConditionalTx(ctx context.Context, signedTx *types.Transaction, options *arbitrum_types.ConditionalOptions) error {
+	if err := a.checkConditionalTxSender(signedTx); err != nil {
+		return err
+	}
+	if options != nil {
+		if err := options.CheckLimits(a.b.config.ConditionalOptionsLimits()); err != nil {
+			return err
+		}
+	}
 	return a.b.EnqueueL2Message(ctx, signedTx, options)
 }
 
@@ -497,6 +839,35 @@ func (a *APIBackend) GetTransaction(ctx context.Context, txHash common.Hash) (*t
 	return tx, blockHash, blockNumber, index, nil
 }
 
+// GetTransactionByBlockNumberAndIndex returns the transaction at the given index of a block, or nil if the index is out of range
+func (a *APIBackend) GetTransactionByBlockNumberAndIndex(ctx context.Context, number rpc.BlockNumber, index uint) (*types.Transaction, error) {
+	block, err := a.BlockByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	return transactionAtIndex(block, index)
+}
+
+// GetTransactionByBlockHashAndIndex returns the transaction at the given index of a block, or nil if the index is out of range
+func (a *APIBackend) GetTransactionByBlockHashAndIndex(ctx context.Context, hash common.Hash, index uint) (*types.Transaction, error) {
+	block, err := a.BlockByHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	return transactionAtIndex(block, index)
+}
+
+func transactionAtIndex(block *types.Block, index uint) (*types.Transaction, error) {
+	if block == nil {
+		return nil, errors.New("block not found")
+	}
+	txs := block.Transactions()
+	if index >= uint(len(txs)) {
+		return nil, nil
+	}
+	return txs[index], nil
+}
+
 func (a *APIBackend) GetPoolTransactions() (types.Transactions, error) {
 	// Arbitrum doesn't have a pool
 	return types.Transactions{}, nil
@@ -516,15 +887,15 @@ func (a *APIBackend) GetPoolNonce(ctx context.Context, addr common.Address) (uin
 }
 
 func (a *APIBackend) Stats() (pending int, queued int) {
-	panic("not implemented") // TODO: Implement
+	return a.b.TxStats()
 }
 
 func (a *APIBackend) TxPoolContent() (map[common.Address]types.Transactions, map[common.Address]types.Transactions) {
-	panic("not implemented") // TODO: Implement
+	return a.b.TxPoolContent()
 }
 
 func (a *APIBackend) TxPoolContentFrom(addr common.Address) (types.Transactions, types.Transactions) {
-	panic("not implemented") // TODO: Implement
+	return a.b.TxPoolContentFrom(addr)
 }
 
 func (a *APIBackend) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.Subscription {
@@ -538,12 +909,44 @@ func (a *APIBackend) BloomStatus() (uint64, uint64) {
 }
 
 func (a *APIBackend) GetLogs(ctx context.Context, hash common.Hash, number uint64) ([][]*types.Log, error) {
+	log.Trace("Reading block logs", withRequestID(ctx, "number", number, "hash", hash)...)
+	if head := a.CurrentHeader(); head != nil && number > head.Number.Uint64() {
+		return nil, fmt.Errorf("block %d not found: beyond head %d", number, head.Number.Uint64())
+	}
 	return rawdb.ReadLogs(a.ChainDb(), hash, number, a.ChainConfig()), nil
 }
 
 func (a *APIBackend) ServiceFilter(ctx context.Context, session *bloombits.MatcherSession) {
-	for i := 0; i < bloomFilterThreads; i++ {
-		go session.Multiplex(bloomRetrievalBatch, bloomRetrievalWait, a.b.bloomRequests)
+	slots := a.b.bloomRetrievalSlots
+	if slots == nil {
+		for i := 0; i < bloomFilterThreads; i++ {
+			go session.Multiplex(bloomRetrievalBatch, bloomRetrievalWait, a.b.bloomRequests)
+		}
+		return
+	}
+	// Wait for one slot so the session makes progress, then only take the slots that are free right away.
+	// A session holding a slot always completes and frees it, so sessions can't starve each other.
+	threads := 0
+	select {
+	case slots <- struct{}{}:
+		threads++
+	case <-ctx.Done():
+		return
+	}
+acquire:
+	for threads < bloomFilterThreads {
+		select {
+		case slots <- struct{}{}:
+			threads++
+		default:
+			break acquire
+		}
+	}
+	for i := 0; i < threads; i++ {
+		go func() {
+			defer func() { <-slots }()
+			session.Multiplex(bloomRetrievalBatch, bloomRetrievalWait, a.b.bloomRequests)
+		}()
 	}
 }
 
@@ -552,8 +955,18 @@ func (a *APIBackend) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscripti
 }
 
 func (a *APIBackend) SubscribePendingLogsEvent(ch chan<- []*types.Log) event.Subscription {
-	//Arbitrum doesn't really need pending logs. Logs are published as soon as we know them..
-	return a.SubscribeLogsEvent(ch)
+	switch a.b.config.PendingLogsBehavior {
+	case PendingLogsEmpty:
+		return event.NewSubscription(func(quit <-chan struct{}) error {
+			<-quit
+			return nil
+		})
+	case PendingLogsProjected:
+		return a.b.scope.Track(a.b.pendingLogsFeed.Subscribe(ch))
+	default:
+		//Arbitrum doesn't really need pending logs. Logs are published as soon as we know them..
+		return a.SubscribeLogsEvent(ch)
+	}
 }
 
 func (a *APIBackend) SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.Subscription {
@@ -568,10 +981,6 @@ func (a *APIBackend) Engine() consensus.Engine {
 	return a.blockChain().Engine()
 }
 
-func (b *APIBackend) PendingBlockAndReceipts() (*types.Block, types.Receipts) {
-	return nil, nil
-}
-
 func (b *APIBackend) FallbackClient() types.FallbackClient {
 	return b.fallbackClient
 }
@@ -0,0 +1,913 @@
+package arbitrum
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	ethereum "github.com/youngqqcn/arbitrum"
+	"github.com/youngqqcn/arbitrum/arbitrum_types"
+	"github.com/youngqqcn/arbitrum/common"
+	"github.com/youngqqcn/arbitrum/common/lru"
+	"github.com/youngqqcn/arbitrum/consensus/ethash"
+	"github.com/youngqqcn/arbitrum/core"
+	"github.com/youngqqcn/arbitrum/core/rawdb"
+	"github.com/youngqqcn/arbitrum/core/state"
+	"github.com/youngqqcn/arbitrum/core/types"
+	"github.com/youngqqcn/arbitrum/core/vm"
+	"github.com/youngqqcn/arbitrum/crypto"
+	"github.com/youngqqcn/arbitrum/eth/filters"
+	"github.com/youngqqcn/arbitrum/eth/tracers/logger"
+	"github.com/youngqqcn/arbitrum/event"
+	"github.com/youngqqcn/arbitrum/node"
+	"github.com/youngqqcn/arbitrum/params"
+	"github.com/youngqqcn/arbitrum/rlp"
+	"github.com/youngqqcn/arbitrum/rpc"
+)
+
+var (
+	testKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testAddr    = crypto.PubkeyToAddress(testKey.PublicKey)
+	testBalance = big.NewInt(2e18)
+
+	// testEmitter is a contract emitting a single empty LOG0 on every call
+	testEmitter      = common.HexToAddress("0x00000000000000000000000000000000000e1717")
+	testEmitterAlloc = core.GenesisAlloc{
+		testEmitter: {Balance: common.Big0, Code: common.FromHex("0x60006000a000")},
+	}
+)
+
+type testArbInterface struct {
+	chain      *core.BlockChain
+	published  []*types.Transaction
+	publishErr error
+
+	batches   map[uint64][]byte            // batch payloads by block number
+	positions map[uint64]testBatchPosition // batch positions by block number
+	sequence  map[common.Hash]uint64       // sequencer message indexes by tx hash
+	upgrade   *ArbOSUpgrade
+	delayed   uint64              // delayed inbox message count
+	l1Fees    map[uint64]*big.Int // L1 base fees paid by block number
+	health    event.Feed
+
+	checkConditions bool // whether to check conditional options against the head state, like the sequencer
+}
+
+func (a *testArbInterface) PublishTransaction(ctx context.Context, tx *types.Transaction, options *arbitrum_types.ConditionalOptions) error {
+	if a.publishErr != nil {
+		return a.publishErr
+	}
+	if a.checkConditions && options != nil {
+		statedb, err := a.chain.State()
+		if err != nil {
+			return err
+		}
+		header := a.chain.CurrentHeader()
+		l1BlockNumber := types.DeserializeHeaderExtraInformation(header).L1BlockNumber
+		if err := options.Check(l1BlockNumber, header.Time, statedb); err != nil {
+			return err
+		}
+	}
+	a.published = append(a.published, tx)
+	return nil
+}
+
+func (a *testArbInterface) BlockChain() *core.BlockChain {
+	return a.chain
+}
+
+func (a *testArbInterface) ArbNode() interface{} {
+	return nil
+}
+
+func (a *testArbInterface) BatchDataForBlock(ctx context.Context, blockNum uint64) ([]byte, error) {
+	data, ok := a.batches[blockNum]
+	if !ok {
+		return nil, errors.New("batch not found")
+	}
+	return data, nil
+}
+
+type testBatchPosition struct {
+	batch uint64
+	index uint64
+}
+
+func (a *testArbInterface) BatchPositionForBlock(ctx context.Context, blockNum uint64) (uint64, uint64, error) {
+	position, ok := a.positions[blockNum]
+	if !ok {
+		return 0, 0, errors.New("batch not found")
+	}
+	return position.batch, position.index, nil
+}
+
+func (a *testArbInterface) TransactionSequenceNumber(ctx context.Context, txHash common.Hash) (uint64, bool, error) {
+	seqNum, ok := a.sequence[txHash]
+	return seqNum, ok, nil
+}
+
+func (a *testArbInterface) ScheduledArbOSUpgrade(ctx context.Context) (*ArbOSUpgrade, error) {
+	return a.upgrade, nil
+}
+
+func (a *testArbInterface) DelayedMessageCount(ctx context.Context) (uint64, error) {
+	return a.delayed, nil
+}
+
+func (a *testArbInterface) L1BaseFeeForBlock(ctx context.Context, blockNum uint64) (*big.Int, error) {
+	fee, ok := a.l1Fees[blockNum]
+	if !ok {
+		return nil, errors.New("batch not found")
+	}
+	return fee, nil
+}
+
+func (a *testArbInterface) SubscribeSequencerHealthReports(ch chan<- SequencerHealthEvent) event.Subscription {
+	return a.health.Subscribe(ch)
+}
+
+type testSyncProgress struct {
+	progress  map[string]interface{}
+	safe      uint64
+	finalized uint64
+	err       error
+}
+
+func (s *testSyncProgress) SyncProgressMap() map[string]interface{} {
+	return s.progress
+}
+
+func (s *testSyncProgress) SafeBlockNumber(ctx context.Context) (uint64, error) {
+	return s.safe, s.err
+}
+
+func (s *testSyncProgress) FinalizedBlockNumber(ctx context.Context) (uint64, error) {
+	return s.finalized, s.err
+}
+
+// testSyncTarget is a sync progress backend that knows the head it's syncing towards
+type testSyncTarget struct {
+	testSyncProgress
+	starting uint64
+	highest  uint64
+}
+
+func (s *testSyncTarget) SyncTarget() (uint64, uint64) {
+	return s.starting, s.highest
+}
+
+// newTestAPIBackend creates an APIBackend on top of an in-memory chain of n blocks
+// following an Arbitrum genesis.
+func newTestAPIBackend(t testing.TB, n int, generator func(i int, b *core.BlockGen)) (*APIBackend, []*types.Block) {
+	t.Helper()
+	return newTestAPIBackendWithAlloc(t, nil, n, generator)
+}
+
+// newTestAPIBackendWithAlloc is like newTestAPIBackend, with extra accounts added to the genesis.
+func newTestAPIBackendWithAlloc(t testing.TB, alloc core.GenesisAlloc, n int, generator func(i int, b *core.BlockGen)) (*APIBackend, []*types.Block) {
+	t.Helper()
+	return newTestAPIBackendWithCache(t, nil, alloc, n, generator)
+}
+
+// newPrunedTestAPIBackend is like newTestAPIBackend, but only keeps the state of the genesis
+// and the last triesInMemory blocks.
+func newPrunedTestAPIBackend(t *testing.T, triesInMemory uint64, n int, generator func(i int, b *core.BlockGen)) (*APIBackend, []*types.Block) {
+	t.Helper()
+	cacheConfig := &core.CacheConfig{
+		TrieCleanLimit: 256,
+		TrieDirtyLimit: 256,
+		TrieTimeLimit:  5 * time.Minute,
+		TriesInMemory:  triesInMemory,
+		TrieRetention:  0,
+	}
+	return newTestAPIBackendWithCache(t, cacheConfig, nil, n, generator)
+}
+
+// newTestAPIBackendWithCache is like newTestAPIBackendWithAlloc, with the chain using the given cache config.
+func newTestAPIBackendWithCache(t testing.TB, cacheConfig *core.CacheConfig, alloc core.GenesisAlloc, n int, generator func(i int, b *core.BlockGen)) (*APIBackend, []*types.Block) {
+	t.Helper()
+	gspec := &core.Genesis{
+		Config:  params.ArbitrumDevTestChainConfig(),
+		Alloc:   core.GenesisAlloc{testAddr: {Balance: testBalance}},
+		BaseFee: big.NewInt(params.InitialBaseFee),
+	}
+	for addr, account := range alloc {
+		gspec.Alloc[addr] = account
+	}
+	return newTestAPIBackendWithGenesis(t, gspec, cacheConfig, n, generator)
+}
+
+// newTestAPIBackendWithGenesis creates an APIBackend on top of an in-memory chain of n blocks following the given genesis.
+func newTestAPIBackendWithGenesis(t testing.TB, gspec *core.Genesis, cacheConfig *core.CacheConfig, n int, generator func(i int, b *core.BlockGen)) (*APIBackend, []*types.Block) {
+	t.Helper()
+	engine := ethash.NewFaker()
+	_, blocks, _ := core.GenerateChainWithGenesis(gspec, engine, n, generator)
+
+	db := rawdb.NewMemoryDatabase()
+	gspec.MustCommit(db)
+	chain, err := core.NewBlockChain(db, cacheConfig, gspec.Config, gspec, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	t.Cleanup(chain.Stop)
+
+	config := DefaultConfig
+	backend := &Backend{
+		arb:     &testArbInterface{chain: chain},
+		config:  &config,
+		chainDb: db,
+
+		seenTxs:  lru.NewCache[common.Hash, time.Time](seenTxsCacheSize),
+		inFlight: newInFlightTxs(maxInFlightTxs),
+
+		conditionalOptions: lru.NewCache[common.Hash, *arbitrum_types.ConditionalOptions](conditionalOptionsCacheSize),
+
+		recentReorgs: newReorgHistory(recentReorgsSize),
+
+		publishErrorMapper: MapPublishError,
+
+		chanTxs:      make(chan *types.Transaction, 100),
+		chanClose:    make(chan struct{}),
+		chanNewBlock: make(chan struct{}, 1),
+	}
+	backend.apiBackend = &APIBackend{
+		b:               backend,
+		sync:            &testSyncProgress{},
+		feeHistoryCache: newFeeHistoryCache(config.FeeHistoryCacheSize),
+		classicMisses:   lru.NewCache[common.Hash, struct{}](classicMissesCacheSize),
+	}
+	return backend.apiBackend, blocks
+}
+
+// transferGenerator returns a chain generator adding txsPerBlock value transfers from testAddr to each block.
+func transferGenerator(t testing.TB, txsPerBlock int) func(i int, b *core.BlockGen) {
+	signer := types.LatestSigner(params.ArbitrumDevTestChainConfig())
+	nonce := uint64(0)
+	return func(i int, b *core.BlockGen) {
+		for j := 0; j < txsPerBlock; j++ {
+			tx, err := types.SignTx(types.NewTransaction(nonce, common.Address{0xaa}, big.NewInt(1000), params.TxGas, b.BaseFee(), nil), signer, testKey)
+			if err != nil {
+				t.Fatalf("failed to sign tx: %v", err)
+			}
+			b.AddTx(tx)
+			nonce++
+		}
+	}
+}
+
+// emitterGenerator returns a chain generator adding txsPerBlock calls to testEmitter to each block,
+// starting at the given sender nonce.
+func emitterGenerator(t *testing.T, txsPerBlock int, nonce uint64) func(i int, b *core.BlockGen) {
+	signer := types.LatestSigner(params.ArbitrumDevTestChainConfig())
+	return func(i int, b *core.BlockGen) {
+		for j := 0; j < txsPerBlock; j++ {
+			tx, err := types.SignTx(types.NewTransaction(nonce, testEmitter, common.Big0, 100000, b.BaseFee(), nil), signer, testKey)
+			if err != nil {
+				t.Fatalf("failed to sign tx: %v", err)
+			}
+			b.AddTx(tx)
+			nonce++
+		}
+	}
+}
+
+// signTestTransfer signs a value transfer from testAddr with the given nonce.
+func signTestTransfer(t *testing.T, nonce uint64) *types.Transaction {
+	t.Helper()
+	signer := types.LatestSigner(params.ArbitrumDevTestChainConfig())
+	tx, err := types.SignTx(types.NewTransaction(nonce, common.Address{0xaa}, big.NewInt(1000), params.TxGas, big.NewInt(params.InitialBaseFee), nil), signer, testKey)
+	if err != nil {
+		t.Fatalf("failed to sign tx: %v", err)
+	}
+	return tx
+}
+
+func setTestSpeedLimit(t testing.TB, speedLimit uint64) {
+	t.Helper()
+	prev := core.GetArbOSSpeedLimitPerSecond
+	core.GetArbOSSpeedLimitPerSecond = func(statedb *state.StateDB) (uint64, error) {
+		return speedLimit, nil
+	}
+	t.Cleanup(func() { core.GetArbOSSpeedLimitPerSecond = prev })
+}
+
+func TestFeeHistoryOmitProjected(t *testing.T) {
+	setTestSpeedLimit(t, 7_000_000)
+	api, _ := newTestAPIBackend(t, 3, transferGenerator(t, 1))
+
+	tests := []struct {
+		omitProjected bool
+		want          int
+	}{
+		{false, 3},
+		{true, 2},
+	}
+	for _, tt := range tests {
+		api.b.config.FeeHistoryOmitProjected = tt.omitProjected
+		_, _, basefees, gasUsed, err := api.FeeHistory(context.Background(), 2, rpc.LatestBlockNumber, nil)
+		if err != nil {
+			t.Fatalf("omitProjected=%v: fee history failed: %v", tt.omitProjected, err)
+		}
+		if len(basefees) != tt.want {
+			t.Errorf("omitProjected=%v: wrong number of base fees: have %d, want %d", tt.omitProjected, len(basefees), tt.want)
+		}
+		if len(gasUsed) != 2 {
+			t.Errorf("omitProjected=%v: wrong number of gas used ratios: have %d, want 2", tt.omitProjected, len(gasUsed))
+		}
+	}
+}
+
+func TestFeeHistoryClampToChain(t *testing.T) {
+	setTestSpeedLimit(t, 7_000_000)
+	api, _ := newTestAPIBackend(t, 3, transferGenerator(t, 1))
+
+	oldest, rewards, basefees, gasUsed, err := api.FeeHistory(context.Background(), 1000, rpc.LatestBlockNumber, []float64{50})
+	if err != nil {
+		t.Fatalf("fee history failed: %v", err)
+	}
+	// the genesis and the 3 blocks on top of it
+	if oldest.Sign() != 0 {
+		t.Errorf("wrong oldest block: have %v, want 0", oldest)
+	}
+	if len(gasUsed) != 4 || len(rewards) != 4 {
+		t.Errorf("wrong number of blocks: have %d gas used ratios and %d rewards, want 4", len(gasUsed), len(rewards))
+	}
+	if len(basefees) != 5 {
+		t.Errorf("wrong number of base fees: have %d, want 5", len(basefees))
+	}
+
+	api.b.config.FeeHistoryMaxBlockCount = 2
+	_, _, _, gasUsed, err = api.FeeHistory(context.Background(), 1000, rpc.LatestBlockNumber, nil)
+	if err != nil {
+		t.Fatalf("fee history failed: %v", err)
+	}
+	if len(gasUsed) != 2 {
+		t.Errorf("wrong number of blocks with max block count: have %d, want 2", len(gasUsed))
+	}
+}
+
+func TestFeeHistoryEmptyRewards(t *testing.T) {
+	setTestSpeedLimit(t, 7_000_000)
+	api, _ := newTestAPIBackend(t, 3, transferGenerator(t, 1))
+
+	api.b.config.FeeHistoryEmptyRewards = false
+	_, rewards, _, _, err := api.FeeHistory(context.Background(), 2, rpc.LatestBlockNumber, nil)
+	if err != nil {
+		t.Fatalf("fee history failed: %v", err)
+	}
+	if rewards != nil {
+		t.Errorf("expected nil rewards by default, have %v", rewards)
+	}
+
+	api.b.config.FeeHistoryEmptyRewards = true
+	_, rewards, _, _, err = api.FeeHistory(context.Background(), 2, rpc.LatestBlockNumber, nil)
+	if err != nil {
+		t.Fatalf("fee history failed: %v", err)
+	}
+	if rewards == nil {
+		t.Fatal("expected non-nil rewards")
+	}
+	if len(rewards) != 2 {
+		t.Fatalf("wrong number of reward entries: have %d, want 2", len(rewards))
+	}
+	for i, reward := range rewards {
+		if reward == nil || len(reward) != 0 {
+			t.Errorf("reward entry %d: have %v, want empty non-nil slice", i, reward)
+		}
+	}
+}
+
+func TestSendTxDeduplication(t *testing.T) {
+	api, _ := newTestAPIBackend(t, 0, nil)
+	arb := api.b.arb.(*testArbInterface)
+
+	tx := signTestTransfer(t, 0)
+	if err := api.SendTx(context.Background(), tx); err != nil {
+		t.Fatalf("failed to send tx: %v", err)
+	}
+	// The sequencer would now reject the tx for its nonce, the retry must not reach it
+	arb.publishErr = core.ErrNonceTooLow
+	if err := api.SendTx(context.Background(), tx); err != nil {
+		t.Fatalf("resubmission of accepted tx failed: %v", err)
+	}
+	if len(arb.published) != 1 {
+		t.Errorf("wrong number of published txs: have %d, want 1", len(arb.published))
+	}
+}
+
+func TestSafeFinalizedNotYetAvailable(t *testing.T) {
+	api, _ := newTestAPIBackend(t, 2, nil)
+	api.sync = &testSyncProgress{err: errors.New("no batch posted yet")}
+
+	for _, number := range []rpc.BlockNumber{rpc.SafeBlockNumber, rpc.FinalizedBlockNumber} {
+		api.b.config.SafeFinalizedDefaultToGenesis = false
+		if _, err := api.HeaderByNumber(context.Background(), number); !errors.Is(err, ErrBlockNotYetAvailable) {
+			t.Errorf("%v: wrong error: have %v, want %v", number, err, ErrBlockNotYetAvailable)
+		}
+
+		api.b.config.SafeFinalizedDefaultToGenesis = true
+		header, err := api.HeaderByNumber(context.Background(), number)
+		if err != nil {
+			t.Fatalf("%v: failed to get header: %v", number, err)
+		}
+		if want := api.ChainConfig().ArbitrumChainParams.GenesisBlockNum; header.Number.Uint64() != want {
+			t.Errorf("%v: wrong block: have %d, want %d", number, header.Number, want)
+		}
+	}
+}
+
+func TestSetSyncProgressBackend(t *testing.T) {
+	api, _ := newTestAPIBackend(t, 3, nil)
+	if progress := api.SyncProgress(); progress.HighestBlock != 0 {
+		t.Fatalf("synced node reported progress: %+v", progress)
+	}
+
+	syncing := &testSyncProgress{
+		progress: map[string]interface{}{"batchSeen": uint64(5)},
+		safe:     2,
+	}
+	if err := api.SetSyncProgressBackend(syncing); err != nil {
+		t.Fatalf("failed to set sync progress backend: %v", err)
+	}
+	if progress := api.SyncProgress(); progress.HighestBlock == 0 {
+		t.Error("syncing node didn't report progress")
+	}
+	if progress := api.SyncProgressMap(); progress["batchSeen"] != uint64(5) {
+		t.Errorf("wrong sync progress map: %v", progress)
+	}
+	header, err := api.HeaderByNumber(context.Background(), rpc.SafeBlockNumber)
+	if err != nil {
+		t.Fatalf("failed to get safe header: %v", err)
+	}
+	if header.Number.Uint64() != 2 {
+		t.Errorf("wrong safe block: have %d, want 2", header.Number)
+	}
+	if err := api.SetSyncProgressBackend(nil); err == nil {
+		t.Error("expected error setting a nil sync progress backend")
+	}
+}
+
+func TestDefaultBlockParam(t *testing.T) {
+	api, blocks := newTestAPIBackend(t, 4, nil)
+	api.sync = &testSyncProgress{safe: 3, finalized: 2}
+
+	tests := []struct {
+		param string
+		want  uint64
+	}{
+		{"", 4},
+		{"latest", 4},
+		{"safe", 3},
+		{"finalized", 2},
+		{"0x1", 1},
+	}
+	for _, tt := range tests {
+		api.b.config.DefaultBlockParam = tt.param
+		if err := api.b.config.Validate(); err != nil {
+			t.Errorf("%q: valid default block parameter rejected: %v", tt.param, err)
+		}
+		header, err := api.HeaderByNumberOrHash(context.Background(), rpc.BlockNumberOrHash{})
+		if err != nil {
+			t.Fatalf("%q: failed to get header: %v", tt.param, err)
+		}
+		if header.Number.Uint64() != tt.want {
+			t.Errorf("%q: wrong default header: have %d, want %d", tt.param, header.Number, tt.want)
+		}
+		block, err := api.BlockByNumberOrHash(context.Background(), rpc.BlockNumberOrHash{})
+		if err != nil {
+			t.Fatalf("%q: failed to get block: %v", tt.param, err)
+		}
+		if block.NumberU64() != tt.want {
+			t.Errorf("%q: wrong default block: have %d, want %d", tt.param, block.NumberU64(), tt.want)
+		}
+	}
+
+	// An explicit block reference overrides the default
+	api.b.config.DefaultBlockParam = "finalized"
+	header, err := api.HeaderByNumberOrHash(context.Background(), rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber))
+	if err != nil {
+		t.Fatalf("failed to get latest header: %v", err)
+	}
+	if header.Hash() != blocks[3].Hash() {
+		t.Errorf("wrong latest header: have %d, want %d", header.Number, blocks[3].NumberU64())
+	}
+
+	api.b.config.DefaultBlockParam = "bogus"
+	if err := api.b.config.Validate(); err == nil {
+		t.Error("invalid default block parameter passed validation")
+	}
+	if _, err := api.HeaderByNumberOrHash(context.Background(), rpc.BlockNumberOrHash{}); err == nil {
+		t.Error("expected error for invalid default block parameter")
+	}
+}
+
+func TestGetTransactionByBlockAndIndex(t *testing.T) {
+	api, blocks := newTestAPIBackend(t, 2, transferGenerator(t, 2))
+	block := blocks[1]
+
+	byNumber, err := api.GetTransactionByBlockNumberAndIndex(context.Background(), rpc.BlockNumber(block.NumberU64()), 1)
+	if err != nil {
+		t.Fatalf("failed to get tx by number: %v", err)
+	}
+	byHash, err := api.GetTransactionByBlockHashAndIndex(context.Background(), block.Hash(), 1)
+	if err != nil {
+		t.Fatalf("failed to get tx by hash: %v", err)
+	}
+	want := block.Transactions()[1].Hash()
+	if byNumber == nil || byNumber.Hash() != want {
+		t.Errorf("wrong tx by number: have %v, want %v", byNumber, want)
+	}
+	if byHash == nil || byHash.Hash() != want {
+		t.Errorf("wrong tx by hash: have %v, want %v", byHash, want)
+	}
+
+	// Out of range index
+	if tx, err := api.GetTransactionByBlockNumberAndIndex(context.Background(), rpc.BlockNumber(block.NumberU64()), 2); tx != nil || err != nil {
+		t.Errorf("out of range by number: have (%v, %v), want (nil, nil)", tx, err)
+	}
+	if tx, err := api.GetTransactionByBlockHashAndIndex(context.Background(), block.Hash(), 2); tx != nil || err != nil {
+		t.Errorf("out of range by hash: have (%v, %v), want (nil, nil)", tx, err)
+	}
+
+	// Unknown block
+	if _, err := api.GetTransactionByBlockNumberAndIndex(context.Background(), 100, 0); err == nil {
+		t.Error("expected error for unknown block number")
+	}
+	if _, err := api.GetTransactionByBlockHashAndIndex(context.Background(), common.Hash{0x01}, 0); err == nil {
+		t.Error("expected error for unknown block hash")
+	}
+}
+
+func TestGetTdReporting(t *testing.T) {
+	api, blocks := newTestAPIBackend(t, 2, nil)
+	hash := blocks[1].Hash()
+
+	if td := api.GetTd(context.Background(), hash); td == nil {
+		t.Error("missing total difficulty with reporting enabled")
+	}
+	api.b.config.ReportTotalDifficulty = false
+	if td := api.GetTd(context.Background(), hash); td != nil {
+		t.Errorf("unexpected total difficulty with reporting disabled: %v", td)
+	}
+}
+
+func TestHeaderAndSizeByNumberOrHash(t *testing.T) {
+	api, blocks := newTestAPIBackend(t, 2, transferGenerator(t, 3))
+
+	for _, block := range blocks {
+		header, size, err := api.HeaderAndSizeByNumberOrHash(context.Background(), rpc.BlockNumberOrHashWithHash(block.Hash(), false))
+		if err != nil {
+			t.Fatalf("block %d: failed to get header and size: %v", block.NumberU64(), err)
+		}
+		if header.Hash() != block.Hash() {
+			t.Errorf("block %d: wrong header: have %v, want %v", block.NumberU64(), header.Hash(), block.Hash())
+		}
+		enc, err := rlp.EncodeToBytes(block)
+		if err != nil {
+			t.Fatalf("block %d: failed to encode: %v", block.NumberU64(), err)
+		}
+		if size != uint64(len(enc)) {
+			t.Errorf("block %d: wrong size: have %d, want %d", block.NumberU64(), size, len(enc))
+		}
+	}
+}
+
+func TestCanonicalHash(t *testing.T) {
+	api, _ := newTestAPIBackend(t, 3, nil)
+
+	head := api.CurrentHeader().Number.Uint64()
+	for number := uint64(0); number <= head; number++ {
+		header, err := api.HeaderByNumber(context.Background(), rpc.BlockNumber(number))
+		if err != nil {
+			t.Fatalf("block %d: failed to get header: %v", number, err)
+		}
+		hash, err := api.CanonicalHash(context.Background(), number)
+		if err != nil {
+			t.Fatalf("block %d: failed to get canonical hash: %v", number, err)
+		}
+		if hash != header.Hash() {
+			t.Errorf("block %d: wrong canonical hash: have %v, want %v", number, hash, header.Hash())
+		}
+	}
+	if _, err := api.CanonicalHash(context.Background(), head+1); err == nil {
+		t.Error("expected error for future block")
+	}
+}
+
+func TestSuggestGasTipCap(t *testing.T) {
+	api, _ := newTestAPIBackend(t, 1, nil)
+
+	tip, err := api.SuggestGasTipCap(context.Background())
+	if err != nil {
+		t.Fatalf("failed to suggest tip: %v", err)
+	}
+	if tip.Sign() != 0 {
+		t.Errorf("wrong default tip: have %v, want 0", tip)
+	}
+
+	api.b.config.MinGasTip = 1_000_000
+	tip, err = api.SuggestGasTipCap(context.Background())
+	if err != nil {
+		t.Fatalf("failed to suggest tip: %v", err)
+	}
+	if tip.Uint64() != 1_000_000 {
+		t.Errorf("wrong tip with configured floor: have %v, want 1000000", tip)
+	}
+}
+
+func TestSyncProgress(t *testing.T) {
+	api, _ := newTestAPIBackend(t, 3, nil)
+	syncingMap := map[string]interface{}{"batchSeen": uint64(5)}
+
+	if progress := api.SyncProgress(); progress != (ethereum.SyncProgress{}) {
+		t.Errorf("synced node reported progress: %+v", progress)
+	}
+
+	api.sync = &testSyncTarget{testSyncProgress: testSyncProgress{progress: syncingMap}, starting: 1, highest: 10}
+	want := ethereum.SyncProgress{StartingBlock: 1, CurrentBlock: 3, HighestBlock: 10}
+	if progress := api.SyncProgress(); progress != want {
+		t.Errorf("wrong progress: have %+v, want %+v", progress, want)
+	}
+
+	// Without a known target, the node still reports being behind
+	api.sync = &testSyncProgress{progress: syncingMap}
+	want = ethereum.SyncProgress{CurrentBlock: 3, HighestBlock: 4}
+	if progress := api.SyncProgress(); progress != want {
+		t.Errorf("wrong progress without target: have %+v, want %+v", progress, want)
+	}
+}
+
+func TestEnabledNamespaces(t *testing.T) {
+	api, _ := newTestAPIBackend(t, 1, nil)
+	stack, err := node.New(&node.Config{})
+	if err != nil {
+		t.Fatalf("failed to create node: %v", err)
+	}
+	defer stack.Close()
+	api.b.stack = stack
+	filterSystem := filters.NewFilterSystem(api, filters.Config{})
+
+	namespaces := func() map[string]bool {
+		found := make(map[string]bool)
+		for _, api := range api.GetAPIs(filterSystem) {
+			found[api.Namespace] = true
+		}
+		return found
+	}
+	if all := namespaces(); len(all) != len(knownNamespaces) {
+		t.Errorf("wrong namespaces by default: have %v, want %v", all, knownNamespaces)
+	}
+
+	api.b.config.EnabledNamespaces = []string{"eth", "net"}
+	if err := api.b.config.Validate(); err != nil {
+		t.Fatalf("valid namespaces rejected: %v", err)
+	}
+	found := namespaces()
+	if len(found) != 2 || !found["eth"] || !found["net"] {
+		t.Errorf("wrong enabled namespaces: have %v, want [eth net]", found)
+	}
+
+	api.b.config.EnabledNamespaces = []string{"eth", "filters"}
+	if err := api.b.config.Validate(); err == nil {
+		t.Error("expected error for unknown namespace")
+	}
+}
+
+func TestExtRPCEnabled(t *testing.T) {
+	api, _ := newTestAPIBackend(t, 1, nil)
+	for _, tt := range []struct {
+		name   string
+		config node.Config
+		want   bool
+	}{
+		{"no external rpc", node.Config{}, false},
+		{"http", node.Config{HTTPHost: "127.0.0.1"}, true},
+		{"ws", node.Config{WSHost: "127.0.0.1"}, true},
+	} {
+		stack, err := node.New(&tt.config)
+		if err != nil {
+			t.Fatalf("%s: failed to create node: %v", tt.name, err)
+		}
+		api.b.stack = stack
+		if enabled := api.ExtRPCEnabled(); enabled != tt.want {
+			t.Errorf("%s: wrong external rpc status: have %v, want %v", tt.name, enabled, tt.want)
+		}
+		stack.Close()
+	}
+}
+
+// testClassicClient serves eth_getBlockByHash like a classic node
+type testClassicClient struct {
+	blocks  map[common.Hash][]byte // JSON blocks by hash
+	fullTxs bool                   // whether the last request asked for full transactions
+	calls   int
+}
+
+func (c *testClassicClient) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	if method != "eth_getBlockByHash" {
+		return fmt.Errorf("unexpected method %s", method)
+	}
+	c.calls++
+	c.fullTxs = args[1].(bool)
+	raw, ok := c.blocks[args[0].(common.Hash)]
+	if !ok {
+		raw = []byte("null")
+	}
+	return json.Unmarshal(raw, result)
+}
+
+func TestGetBodyClassicFallback(t *testing.T) {
+	api, blocks := newTestAPIBackend(t, 1, nil)
+	ctx := context.Background()
+
+	classicHash := common.Hash{0xc1}
+	txs := []*types.Transaction{signTestTransfer(t, 0), signTestTransfer(t, 1)}
+	raw, err := json.Marshal(map[string]interface{}{
+		"hash":         classicHash,
+		"number":       "0x5",
+		"transactions": txs,
+		"uncles":       []common.Hash{},
+	})
+	if err != nil {
+		t.Fatalf("failed to encode classic block: %v", err)
+	}
+
+	// Without a fallback client, unknown bodies aren't found
+	if _, err := api.GetBody(ctx, classicHash, 5); err == nil {
+		t.Fatal("expected error without fallback client")
+	}
+
+	// Pretend the Nitro chain started after the classic block
+	api.ChainConfig().ArbitrumChainParams.GenesisBlockNum = 100
+	classic := &testClassicClient{blocks: map[common.Hash][]byte{classicHash: raw}}
+	api.fallbackClient = classic
+
+	body, err := api.GetBody(ctx, classicHash, 5)
+	if err != nil {
+		t.Fatalf("failed to get classic body: %v", err)
+	}
+	if !classic.fullTxs {
+		t.Error("classic block requested without full transactions")
+	}
+	if len(body.Transactions) != len(txs) {
+		t.Fatalf("wrong number of transactions: have %d, want %d", len(body.Transactions), len(txs))
+	}
+	for i, tx := range body.Transactions {
+		if tx.Hash() != txs[i].Hash() {
+			t.Errorf("transaction %d: wrong hash: have %v, want %v", i, tx.Hash(), txs[i].Hash())
+		}
+	}
+	if _, err := api.GetBody(ctx, common.Hash{0xc2}, 6); err == nil {
+		t.Error("expected error for block unknown to the classic node")
+	}
+
+	// Local bodies are served without asking the classic node
+	api.fallbackClient = &testClassicClient{}
+	head := blocks[len(blocks)-1]
+	body, err = api.GetBody(ctx, head.Hash(), rpc.BlockNumber(head.NumberU64()))
+	if err != nil {
+		t.Fatalf("failed to get local body: %v", err)
+	}
+	if len(body.Transactions) != len(head.Transactions()) {
+		t.Errorf("wrong local body: have %d transactions, want %d", len(body.Transactions), len(head.Transactions()))
+	}
+}
+
+func TestHeaderByHash(t *testing.T) {
+	api, blocks := newTestAPIBackend(t, 2, nil)
+	ctx := context.Background()
+
+	head := blocks[len(blocks)-1]
+	header, err := api.HeaderByHash(ctx, head.Hash())
+	if err != nil {
+		t.Fatalf("failed to get header: %v", err)
+	}
+	if header.Hash() != head.Hash() {
+		t.Errorf("wrong header: have %v, want %v", header.Hash(), head.Hash())
+	}
+	if header, err := api.HeaderByHash(ctx, common.Hash{0xc1}); err == nil || header != nil {
+		t.Errorf("expected error for unknown header, have %v, %v", header, err)
+	}
+
+	// Without classic history, unknown hashes aren't sent to the classic node
+	client := &testClassicClient{}
+	api.fallbackClient = client
+	if _, err := api.HeaderByHash(ctx, common.Hash{0xc1}); err == nil {
+		t.Error("expected error for unknown header")
+	}
+	if client.calls != 0 {
+		t.Errorf("classic node asked for a header of a chain without classic history")
+	}
+
+	// Pretend the Nitro chain started after the classic block
+	api.ChainConfig().ArbitrumChainParams.GenesisBlockNum = 100
+	classicHeader := &types.Header{
+		Number:     big.NewInt(5),
+		Difficulty: common.Big1,
+		GasLimit:   params.GenesisGasLimit,
+		Time:       1234,
+		Extra:      []byte{},
+	}
+	raw, err := json.Marshal(classicHeader)
+	if err != nil {
+		t.Fatalf("failed to encode classic header: %v", err)
+	}
+	classicHash := common.Hash{0xc1}
+	client = &testClassicClient{blocks: map[common.Hash][]byte{classicHash: raw}}
+	api.fallbackClient = client
+
+	header, err = api.HeaderByHash(ctx, classicHash)
+	if err != nil {
+		t.Fatalf("failed to get classic header: %v", err)
+	}
+	if header.Hash() != classicHeader.Hash() {
+		t.Errorf("wrong classic header: have %+v, want %+v", header, classicHeader)
+	}
+	if _, err := api.HeaderByHash(ctx, common.Hash{0xc2}); err == nil {
+		t.Error("expected error for header unknown to the classic node")
+	}
+	calls := client.calls
+	if _, err := api.HeaderByHash(ctx, common.Hash{0xc2}); err == nil {
+		t.Error("expected error for header unknown to the classic node")
+	}
+	if client.calls != calls {
+		t.Error("classic node asked again for a header it doesn't know")
+	}
+	header, err = api.HeaderByHash(ctx, head.Hash())
+	if err != nil || header.Hash() != head.Hash() {
+		t.Errorf("local header not served locally: %v, %v", header, err)
+	}
+}
+
+// testPrecompile is an Arbitrum precompile echoing its input
+type testPrecompile struct{}
+
+func (testPrecompile) RequiredGas([]byte) uint64        { return 0 }
+func (testPrecompile) Run(input []byte) ([]byte, error) { return input, nil }
+
+func TestGetEVMMergesVMConfig(t *testing.T) {
+	precompile := common.HexToAddress("0x64")
+	vm.PrecompiledContractsArbitrum[precompile] = testPrecompile{}
+	defer delete(vm.PrecompiledContractsArbitrum, precompile)
+
+	api, _ := newTestAPIBackend(t, 1, nil)
+	defaults := api.blockChain().GetVMConfig()
+	defaults.EnablePreimageRecording = true
+	defaults.ExtraEips = []int{3855}
+	defaults.Debug = true
+	defaults.Tracer = logger.NewStructLogger(nil)
+
+	statedb, header, err := api.StateAndHeaderByNumber(context.Background(), rpc.LatestBlockNumber)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := types.NewMessage(testAddr, &precompile, 0, common.Big0, 100000, common.Big0, common.Big0, common.Big0, nil, nil, true)
+	// A plain call, as eth_call makes it, must not pick up the default tracer
+	evm, _, err := api.GetEVM(context.Background(), msg, statedb, header, &vm.Config{NoBaseFee: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !evm.Config.NoBaseFee {
+		t.Error("the passed NoBaseFee was dropped")
+	}
+	if evm.Config.Debug || evm.Config.Tracer != nil {
+		t.Error("the default tracer was attached to a plain call")
+	}
+	if !evm.Config.EnablePreimageRecording {
+		t.Error("the default EnablePreimageRecording was dropped")
+	}
+	if fmt.Sprint(evm.Config.ExtraEips) != "[3855]" {
+		t.Errorf("got extra EIPs %v, want the default [3855]", evm.Config.ExtraEips)
+	}
+
+	// Explicitly set fields win over the defaults
+	tracer := logger.NewStructLogger(nil)
+	traced, _, err := api.GetEVM(context.Background(), msg, statedb, header, &vm.Config{Debug: true, Tracer: tracer, ExtraEips: []int{3860}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !traced.Config.Debug || traced.Config.Tracer != tracer {
+		t.Error("the passed tracer was dropped")
+	}
+	if fmt.Sprint(traced.Config.ExtraEips) != "[3860]" {
+		t.Errorf("got extra EIPs %v, want the passed [3860]", traced.Config.ExtraEips)
+	}
+	if len(defaults.ExtraEips) != 1 {
+		t.Errorf("merging modified the default extra EIPs: %v", defaults.ExtraEips)
+	}
+
+	ret, _, err := evm.Call(vm.AccountRef(testAddr), precompile, []byte{1, 2, 3}, 100000, common.Big0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(ret) != string([]byte{1, 2, 3}) {
+		t.Errorf("the Arbitrum precompile isn't active, got %x", ret)
+	}
+}
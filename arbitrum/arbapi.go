@@ -0,0 +1,65 @@
+package arbitrum
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/youngqqcn/arbitrum/arbitrum_types"
+	"github.com/youngqqcn/arbitrum/common"
+	"github.com/youngqqcn/arbitrum/common/hexutil"
+	"github.com/youngqqcn/arbitrum/core/types"
+)
+
+// ArbAPI offers Arbitrum specific RPC methods under the arb namespace
+type ArbAPI struct {
+	b *APIBackend
+}
+
+func NewArbAPI(b *APIBackend) *ArbAPI {
+	return &ArbAPI{b}
+}
+
+// ChainConfig returns the chain config the node runs with, so clients can verify they're on the right chain
+func (s *ArbAPI) ChainConfig() (json.RawMessage, error) {
+	return s.b.b.ExportChainConfig()
+}
+
+// RawConditionalTransaction is a raw signed transaction along with the conditions it may be sequenced under
+type RawConditionalTransaction struct {
+	Tx      hexutil.Bytes                      `json:"tx"`
+	Options *arbitrum_types.ConditionalOptions `json:"options"`
+}
+
+// SendRawTransactionConditionalBatch submits several conditional transactions in order in a single call.
+// Each transaction gets its own result, so a rejected one doesn't prevent the others from being sequenced.
+func (s *ArbAPI) SendRawTransactionConditionalBatch(ctx context.Context, txs []RawConditionalTransaction) ([]ConditionalTxResult, error) {
+	// Check the size before decoding anything
+	if err := s.b.checkConditionalBatchSize(len(txs)); err != nil {
+		return nil, err
+	}
+	results := make([]ConditionalTxResult, len(txs))
+	var submissions []ConditionalTxSubmission
+	var indices []int
+	for i, raw := range txs {
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(raw.Tx); err != nil {
+			results[i] = newConditionalTxResult(common.Hash{}, err)
+			continue
+		}
+		submissions = append(submissions, ConditionalTxSubmission{Tx: tx, Options: raw.Options})
+		indices = append(indices, i)
+	}
+	submitted, err := s.b.SendConditionalTxBatch(ctx, submissions)
+	if err != nil {
+		return nil, err
+	}
+	for i, result := range submitted {
+		results[indices[i]] = result
+	}
+	return results, nil
+}
+
+// GetRecentReorgs returns up to the last count reorgs the node observed, newest first
+func (s *ArbAPI) GetRecentReorgs(ctx context.Context, count int) ([]ReorgRecord, error) {
+	return s.b.GetRecentReorgs(ctx, count)
+}
@@ -0,0 +1,31 @@
+package arbitrum
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/youngqqcn/arbitrum/params"
+)
+
+func TestChainConfigExport(t *testing.T) {
+	api, _ := newTestAPIBackend(t, 0, nil)
+
+	enc, err := NewArbAPI(api).ChainConfig()
+	if err != nil {
+		t.Fatalf("failed to export chain config: %v", err)
+	}
+	var config params.ChainConfig
+	if err := json.Unmarshal(enc, &config); err != nil {
+		t.Fatalf("failed to decode chain config: %v", err)
+	}
+	want := api.ChainConfig()
+	if config.ChainID.Cmp(want.ChainID) != 0 {
+		t.Errorf("wrong chain id: have %v, want %v", config.ChainID, want.ChainID)
+	}
+	if config.LondonBlock.Cmp(want.LondonBlock) != 0 {
+		t.Errorf("wrong london block: have %v, want %v", config.LondonBlock, want.LondonBlock)
+	}
+	if config.ArbitrumChainParams != want.ArbitrumChainParams {
+		t.Errorf("wrong arbitrum params: have %+v, want %+v", config.ArbitrumChainParams, want.ArbitrumChainParams)
+	}
+}
@@ -0,0 +1,105 @@
+package arbitrum
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/youngqqcn/arbitrum/common"
+	"github.com/youngqqcn/arbitrum/core"
+	"github.com/youngqqcn/arbitrum/crypto"
+	"github.com/youngqqcn/arbitrum/rpc"
+)
+
+// arbosStateAddress is the account holding the storage of the ArbOS state
+var arbosStateAddress = common.HexToAddress("0xA4B05FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF")
+
+var (
+	errArbOSNotInstalled = errors.New("ArbOS not installed")
+
+	// ErrNoArbOSUpgradeScheduled is returned when ArbOS has no pending upgrade
+	ErrNoArbOSUpgradeScheduled = errors.New("no ArbOS upgrade scheduled")
+)
+
+// ArbOSUpgrade describes a pending ArbOS upgrade
+type ArbOSUpgrade struct {
+	Version   uint64 `json:"version"`   // the ArbOS version being upgraded to
+	Timestamp uint64 `json:"timestamp"` // the L2 block timestamp from which the upgrade is active
+}
+
+// GetL1BaseFeeEstimate returns the L1 base fee estimate ArbOS maintained as of the given block
+func (a *APIBackend) GetL1BaseFeeEstimate(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*big.Int, error) {
+	if core.GetArbOSL1BaseFeeEstimate == nil {
+		return nil, errArbOSNotInstalled
+	}
+	statedb, _, err := a.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	return core.GetArbOSL1BaseFeeEstimate(statedb)
+}
+
+// GasBacklog is the state of the ArbOS L2 pricing model, from which the next base fees follow
+type GasBacklog struct {
+	Backlog        uint64 `json:"backlog"`        // the gas used beyond the speed limit, not yet paid down
+	PricingInertia uint64 `json:"pricingInertia"` // how slowly the base fee reacts to the backlog
+}
+
+// GetGasBacklog returns the gas backlog and pricing inertia ArbOS maintained as of the given block
+func (a *APIBackend) GetGasBacklog(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*GasBacklog, error) {
+	if core.GetArbOSGasBacklog == nil {
+		return nil, errArbOSNotInstalled
+	}
+	statedb, _, err := a.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	backlog, inertia, err := core.GetArbOSGasBacklog(statedb)
+	if err != nil {
+		return nil, err
+	}
+	return &GasBacklog{Backlog: backlog, PricingInertia: inertia}, nil
+}
+
+// GetScheduledArbOSUpgrade returns the next ArbOS upgrade, or ErrNoArbOSUpgradeScheduled if none is pending
+func (a *APIBackend) GetScheduledArbOSUpgrade(ctx context.Context) (*ArbOSUpgrade, error) {
+	upgrade, err := a.b.arb.ScheduledArbOSUpgrade(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if upgrade == nil {
+		return nil, ErrNoArbOSUpgradeScheduled
+	}
+	return upgrade, nil
+}
+
+// arbosStorageSlot maps a key of an ArbOS storage subspace to its slot in the storage of the ArbOS state account.
+// Like ArbOS, it keeps the last byte of the key so consecutive keys share a trie page.
+func arbosStorageSlot(subspace []byte, key common.Hash) common.Hash {
+	var storageKey []byte
+	if len(subspace) > 0 {
+		storageKey = crypto.Keccak256(storageKey, subspace)
+	}
+	boundary := common.HashLength - 1
+	mapped := make([]byte, 0, common.HashLength)
+	mapped = append(mapped, crypto.Keccak256(storageKey, key[:boundary])[:boundary]...)
+	mapped = append(mapped, key[boundary])
+	return common.BytesToHash(mapped)
+}
+
+// GetArbOSStorageAt returns the raw value at a key of the ArbOS state, as of the given block.
+// An empty subspace reads from the root of the ArbOS state.
+func (a *APIBackend) GetArbOSStorageAt(ctx context.Context, arbosSubspace []byte, key common.Hash, blockNrOrHash rpc.BlockNumberOrHash) (common.Hash, error) {
+	if !a.b.config.AllowDangerousDebugCalls {
+		return common.Hash{}, errDangerousDebugCallsDisabled
+	}
+	statedb, _, err := a.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if statedb == nil {
+		return common.Hash{}, errors.New("state not found")
+	}
+	value := statedb.GetState(arbosStateAddress, arbosStorageSlot(arbosSubspace, key))
+	return value, statedb.Error()
+}
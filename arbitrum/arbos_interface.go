@@ -2,14 +2,35 @@ package arbitrum
 
 import (
 	"context"
+	"math/big"
 
 	"github.com/youngqqcn/arbitrum/arbitrum_types"
+	"github.com/youngqqcn/arbitrum/common"
 	"github.com/youngqqcn/arbitrum/core"
 	"github.com/youngqqcn/arbitrum/core/types"
+	"github.com/youngqqcn/arbitrum/event"
 )
 
 type ArbInterface interface {
+	// PublishTransaction sequences the transaction. ctx is the submitting RPC call's, so conditional options
+	// should be checked with CheckWithContext to stop once the client has given up.
 	PublishTransaction(ctx context.Context, tx *types.Transaction, options *arbitrum_types.ConditionalOptions) error
 	BlockChain() *core.BlockChain
 	ArbNode() interface{}
+	// BatchDataForBlock returns the compressed payload of the sequencer batch that included the block
+	BatchDataForBlock(ctx context.Context, blockNum uint64) ([]byte, error)
+	// BatchPositionForBlock returns the number of the sequencer batch that included the block,
+	// and the block's index among the blocks of that batch
+	BatchPositionForBlock(ctx context.Context, blockNum uint64) (batchNumber uint64, indexInBatch uint64, err error)
+	// TransactionSequenceNumber returns the index of the sequencer message that included the transaction,
+	// and whether the transaction is known
+	TransactionSequenceNumber(ctx context.Context, txHash common.Hash) (uint64, bool, error)
+	// ScheduledArbOSUpgrade returns the ArbOS upgrade the chain owner scheduled, or nil if there's none
+	ScheduledArbOSUpgrade(ctx context.Context) (*ArbOSUpgrade, error)
+	// DelayedMessageCount returns the number of messages in the delayed inbox accumulator
+	DelayedMessageCount(ctx context.Context) (uint64, error)
+	// L1BaseFeeForBlock returns the L1 base fee actually paid when posting the sequencer batch that included the block
+	L1BaseFeeForBlock(ctx context.Context, blockNum uint64) (*big.Int, error)
+	// SubscribeSequencerHealthReports delivers the sequencer's health every time it's assessed
+	SubscribeSequencerHealthReports(ch chan<- SequencerHealthEvent) event.Subscription
 }
@@ -0,0 +1,142 @@
+package arbitrum
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/youngqqcn/arbitrum/common"
+	"github.com/youngqqcn/arbitrum/core"
+	"github.com/youngqqcn/arbitrum/core/state"
+	"github.com/youngqqcn/arbitrum/core/types"
+	"github.com/youngqqcn/arbitrum/crypto"
+	"github.com/youngqqcn/arbitrum/rpc"
+)
+
+// testArbOSSlot is the ArbOS storage slot the test hooks read their values from
+var testArbOSSlot = common.HexToHash("0x01")
+
+func newTestArbOSBackend(t *testing.T, value *big.Int) *APIBackend {
+	t.Helper()
+	alloc := core.GenesisAlloc{
+		types.ArbosAddress: {
+			Balance: common.Big0,
+			Storage: map[common.Hash]common.Hash{testArbOSSlot: common.BigToHash(value)},
+		},
+	}
+	api, _ := newTestAPIBackendWithAlloc(t, alloc, 2, nil)
+	return api
+}
+
+func TestGetL1BaseFeeEstimate(t *testing.T) {
+	estimate := big.NewInt(30_000_000_000)
+	api := newTestArbOSBackend(t, estimate)
+
+	defer func(hook func(*state.StateDB) (*big.Int, error)) { core.GetArbOSL1BaseFeeEstimate = hook }(core.GetArbOSL1BaseFeeEstimate)
+	core.GetArbOSL1BaseFeeEstimate = func(statedb *state.StateDB) (*big.Int, error) {
+		return statedb.GetState(types.ArbosAddress, testArbOSSlot).Big(), nil
+	}
+
+	have, err := api.GetL1BaseFeeEstimate(context.Background(), rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber))
+	if err != nil {
+		t.Fatalf("failed to get l1 base fee estimate: %v", err)
+	}
+	if have.Cmp(estimate) != 0 {
+		t.Errorf("wrong l1 base fee estimate: have %v, want %v", have, estimate)
+	}
+}
+
+func TestGetScheduledArbOSUpgrade(t *testing.T) {
+	api, _ := newTestAPIBackend(t, 0, nil)
+	if _, err := api.GetScheduledArbOSUpgrade(context.Background()); !errors.Is(err, ErrNoArbOSUpgradeScheduled) {
+		t.Fatalf("wrong error without scheduled upgrade: have %v, want %v", err, ErrNoArbOSUpgradeScheduled)
+	}
+
+	want := &ArbOSUpgrade{Version: 11, Timestamp: 1700000000}
+	api.b.arb.(*testArbInterface).upgrade = want
+	upgrade, err := api.GetScheduledArbOSUpgrade(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get scheduled upgrade: %v", err)
+	}
+	if *upgrade != *want {
+		t.Errorf("wrong scheduled upgrade: have %+v, want %+v", upgrade, want)
+	}
+}
+
+func TestGetArbOSStorageAt(t *testing.T) {
+	var (
+		subspace = []byte{1} // e.g. the L1 pricing state
+		key      = common.HexToHash("0x02")
+		value    = common.HexToHash("0x1234")
+	)
+	// The slot of a subspace key is keccak(keccak(subspace), key[:31])[:31] followed by key[31]
+	slot := crypto.Keccak256(crypto.Keccak256(subspace), key[:31])[:31]
+	slot = append(slot, key[31])
+	alloc := core.GenesisAlloc{
+		arbosStateAddress: {
+			Balance: common.Big0,
+			Storage: map[common.Hash]common.Hash{common.BytesToHash(slot): value},
+		},
+	}
+	api, _ := newTestAPIBackendWithAlloc(t, alloc, 1, nil)
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+
+	if _, err := api.GetArbOSStorageAt(context.Background(), subspace, key, latest); err == nil {
+		t.Fatal("expected reading ArbOS storage to require dangerous debug calls")
+	}
+	api.b.config.AllowDangerousDebugCalls = true
+
+	have, err := api.GetArbOSStorageAt(context.Background(), subspace, key, latest)
+	if err != nil {
+		t.Fatalf("failed to read ArbOS storage: %v", err)
+	}
+	if have != value {
+		t.Errorf("wrong ArbOS storage value: have %v, want %v", have, value)
+	}
+	// The same key in the root space maps to a different slot
+	have, err = api.GetArbOSStorageAt(context.Background(), nil, key, latest)
+	if err != nil {
+		t.Fatalf("failed to read ArbOS storage: %v", err)
+	}
+	if have != (common.Hash{}) {
+		t.Errorf("unexpected value in the root space: %v", have)
+	}
+	if _, err := api.GetArbOSStorageAt(context.Background(), subspace, key, rpc.BlockNumberOrHashWithHash(common.Hash{0xff}, false)); err == nil {
+		t.Error("expected error for a block without state")
+	}
+}
+
+func TestGetGasBacklog(t *testing.T) {
+	inertiaSlot := common.HexToHash("0x02")
+	alloc := core.GenesisAlloc{
+		types.ArbosAddress: {
+			Balance: common.Big0,
+			Storage: map[common.Hash]common.Hash{
+				testArbOSSlot: common.BigToHash(big.NewInt(4_500_000)),
+				inertiaSlot:   common.BigToHash(big.NewInt(102)),
+			},
+		},
+	}
+	api, _ := newTestAPIBackendWithAlloc(t, alloc, 2, nil)
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+
+	defer func(hook func(*state.StateDB) (uint64, uint64, error)) { core.GetArbOSGasBacklog = hook }(core.GetArbOSGasBacklog)
+	core.GetArbOSGasBacklog = nil
+	if _, err := api.GetGasBacklog(context.Background(), latest); !errors.Is(err, errArbOSNotInstalled) {
+		t.Fatalf("wrong error without ArbOS: have %v, want %v", err, errArbOSNotInstalled)
+	}
+	core.GetArbOSGasBacklog = func(statedb *state.StateDB) (uint64, uint64, error) {
+		backlog := statedb.GetState(types.ArbosAddress, testArbOSSlot).Big().Uint64()
+		inertia := statedb.GetState(types.ArbosAddress, inertiaSlot).Big().Uint64()
+		return backlog, inertia, nil
+	}
+
+	have, err := api.GetGasBacklog(context.Background(), latest)
+	if err != nil {
+		t.Fatalf("failed to get gas backlog: %v", err)
+	}
+	if want := (GasBacklog{Backlog: 4_500_000, PricingInertia: 102}); *have != want {
+		t.Errorf("wrong gas backlog: have %+v, want %+v", *have, want)
+	}
+}
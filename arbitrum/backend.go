@@ -2,8 +2,12 @@ package arbitrum
 
 import (
 	"context"
+	"encoding/json"
+	"time"
 
 	"github.com/youngqqcn/arbitrum/arbitrum_types"
+	"github.com/youngqqcn/arbitrum/common"
+	"github.com/youngqqcn/arbitrum/common/lru"
 	"github.com/youngqqcn/arbitrum/core"
 	"github.com/youngqqcn/arbitrum/core/bloombits"
 	"github.com/youngqqcn/arbitrum/core/types"
@@ -14,6 +18,20 @@ import (
 	"github.com/youngqqcn/arbitrum/node"
 )
 
+const (
+	// seenTxsCacheSize is the number of recently accepted transaction hashes remembered for deduplication
+	seenTxsCacheSize = 4096
+
+	// seenTxsTTL is how long an accepted transaction is treated as a duplicate when resubmitted
+	seenTxsTTL = time.Minute
+
+	// conditionalOptionsCacheSize is the number of accepted conditional transactions whose options are kept for auditing
+	conditionalOptionsCacheSize = 4096
+
+	// classicMissesCacheSize is the number of hashes unknown to the classic node remembered to avoid asking it again
+	classicMissesCacheSize = 4096
+)
+
 type Backend struct {
 	arb        ArbInterface
 	stack      *node.Node
@@ -21,20 +39,37 @@ type Backend struct {
 	config     *Config
 	chainDb    ethdb.Database
 
-	txFeed event.Feed
-	scope  event.SubscriptionScope
+	txFeed          event.Feed
+	pendingLogsFeed event.Feed
+	scope           event.SubscriptionScope
 
 	bloomRequests chan chan *bloombits.Retrieval // Channel receiving bloom data retrieval requests
 	bloomIndexer  *core.ChainIndexer             // Bloom indexer operating during block imports
 
+	bloomRetrievalSlots chan struct{} // bounds the bloom retrieval goroutines of all filters, nil if unbounded
+
 	shutdownTracker *shutdowncheck.ShutdownTracker
 
+	seenTxs  *lru.Cache[common.Hash, time.Time] // recently accepted transactions, to absorb client retries
+	inFlight *inFlightTxs                       // transactions accepted but not yet included
+
+	conditionalOptions *lru.Cache[common.Hash, *arbitrum_types.ConditionalOptions] // options of accepted conditional transactions
+
+	recentReorgs *reorgHistory // reorgs observed since startup
+
+	publishErrorMapper PublishErrorMapper
+
 	chanTxs      chan *types.Transaction
 	chanClose    chan struct{} //close coroutine
 	chanNewBlock chan struct{} //create new L2 block unless empty
+
+	blockProductionPaused int32 // atomic, non-zero while new block signals are dropped
 }
 
 func NewBackend(stack *node.Node, config *Config, chainDb ethdb.Database, publisher ArbInterface, sync SyncProgressBackend, filterConfig filters.Config) (*Backend, *filters.FilterSystem, error) {
+	if err := config.Validate(); err != nil {
+		return nil, nil, err
+	}
 	backend := &Backend{
 		arb:     publisher,
 		stack:   stack,
@@ -46,11 +81,23 @@ func NewBackend(stack *node.Node, config *Config, chainDb ethdb.Database, publis
 
 		shutdownTracker: shutdowncheck.NewShutdownTracker(chainDb),
 
+		seenTxs:  lru.NewCache[common.Hash, time.Time](seenTxsCacheSize),
+		inFlight: newInFlightTxs(maxInFlightTxs),
+
+		conditionalOptions: lru.NewCache[common.Hash, *arbitrum_types.ConditionalOptions](conditionalOptionsCacheSize),
+
+		recentReorgs: newReorgHistory(recentReorgsSize),
+
+		publishErrorMapper: MapPublishError,
+
 		chanTxs:      make(chan *types.Transaction, 100),
 		chanClose:    make(chan struct{}),
 		chanNewBlock: make(chan struct{}, 1),
 	}
 
+	if config.MaxBloomRetrievalGoroutines > 0 {
+		backend.bloomRetrievalSlots = make(chan struct{}, config.MaxBloomRetrievalGoroutines)
+	}
 	backend.bloomIndexer.Start(backend.arb.BlockChain())
 	filterSystem, err := createRegisterAPIBackend(backend, sync, filterConfig, config.ClassicRedirect, config.ClassicRedirectTimeout)
 	if err != nil {
@@ -68,7 +115,42 @@ func (b *Backend) ChainDb() ethdb.Database {
 }
 
 func (b *Backend) EnqueueL2Message(ctx context.Context, tx *types.Transaction, options *arbitrum_types.ConditionalOptions) error {
-	return b.arb.PublishTransaction(ctx, tx, options)
+	// A client retrying an already accepted transaction gets the original result
+	// rather than a confusing nonce error from the sequencer
+	hash := tx.Hash()
+	if acceptedAt, ok := b.seenTxs.Get(hash); ok {
+		if time.Since(acceptedAt) < seenTxsTTL {
+			return nil
+		}
+		b.seenTxs.Remove(hash)
+	}
+	from, senderErr := types.Sender(types.LatestSigner(b.arb.BlockChain().Config()), tx)
+	if senderErr == nil {
+		b.inFlight.add(tx, from)
+	}
+	if err := b.arb.PublishTransaction(ctx, tx, options); err != nil {
+		b.inFlight.remove(hash)
+		if b.publishErrorMapper != nil {
+			return b.publishErrorMapper(err)
+		}
+		return err
+	}
+	b.inFlight.published(hash)
+	b.seenTxs.Add(hash, time.Now())
+	if options != nil {
+		b.conditionalOptions.Add(hash, options)
+	}
+	return nil
+}
+
+// SetPublishErrorMapper replaces how errors from PublishTransaction are reported to RPC clients
+func (b *Backend) SetPublishErrorMapper(mapper PublishErrorMapper) {
+	b.publishErrorMapper = mapper
+}
+
+// ExportChainConfig returns the JSON encoding of the chain config, including the Arbitrum chain parameters
+func (b *Backend) ExportChainConfig() ([]byte, error) {
+	return json.Marshal(b.arb.BlockChain().Config())
 }
 
 func (b *Backend) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.Subscription {
@@ -86,6 +168,11 @@ func (b *Backend) ArbInterface() ArbInterface {
 // TODO: this is used when registering backend as lifecycle in stack
 func (b *Backend) Start() error {
 	b.startBloomHandlers(b.config.BloomBitsBlocks)
+	go b.recordReorgs()
+	go b.pruneInFlight()
+	if b.config.BloomLagWarnThreshold > 0 {
+		go b.monitorBloomLag()
+	}
 	b.shutdownTracker.MarkStartup()
 	b.shutdownTracker.Start()
 
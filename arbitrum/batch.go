@@ -0,0 +1,74 @@
+package arbitrum
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/youngqqcn/arbitrum/common"
+	"github.com/youngqqcn/arbitrum/core/types"
+	"github.com/youngqqcn/arbitrum/rpc"
+)
+
+// ErrTransactionNotSequenced is returned when the sequencer doesn't know a transaction
+var ErrTransactionNotSequenced = errors.New("transaction not found")
+
+// postNitroBlockNumber resolves a block reference to the number of a block produced by Nitro
+func (a *APIBackend) postNitroBlockNumber(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (uint64, error) {
+	header, err := a.HeaderByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return 0, err
+	}
+	if header == nil {
+		return 0, errors.New("header not found")
+	}
+	if !a.ChainConfig().IsArbitrumNitro(header.Number) {
+		return 0, types.ErrUseFallback
+	}
+	return header.Number.Uint64(), nil
+}
+
+// GetBatchRawData returns the compressed sequencer batch payload that included the given block
+func (a *APIBackend) GetBatchRawData(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]byte, error) {
+	blockNum, err := a.postNitroBlockNumber(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	return a.b.arb.BatchDataForBlock(ctx, blockNum)
+}
+
+// GetBlockBatchPosition returns the sequencer batch that included the given block, and the block's index within it
+func (a *APIBackend) GetBlockBatchPosition(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (batchNumber uint64, indexInBatch uint64, err error) {
+	blockNum, err := a.postNitroBlockNumber(ctx, blockNrOrHash)
+	if err != nil {
+		return 0, 0, err
+	}
+	return a.b.arb.BatchPositionForBlock(ctx, blockNum)
+}
+
+// GetL1BaseFeePaid returns the L1 base fee paid when posting the batch that included the given block.
+// Unlike ArbOS's L1 base fee estimate, this is what the batch poster was actually charged.
+func (a *APIBackend) GetL1BaseFeePaid(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*big.Int, error) {
+	blockNum, err := a.postNitroBlockNumber(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	return a.b.arb.L1BaseFeeForBlock(ctx, blockNum)
+}
+
+// GetTransactionSequenceNumber returns the global index of the sequencer message that included the transaction
+func (a *APIBackend) GetTransactionSequenceNumber(ctx context.Context, txHash common.Hash) (uint64, error) {
+	seqNum, found, err := a.b.arb.TransactionSequenceNumber(ctx, txHash)
+	if err != nil {
+		return 0, err
+	}
+	if !found {
+		return 0, ErrTransactionNotSequenced
+	}
+	return seqNum, nil
+}
+
+// GetDelayedInboxCount returns the number of L1 to L2 messages the delayed inbox has received
+func (a *APIBackend) GetDelayedInboxCount(ctx context.Context) (uint64, error) {
+	return a.b.arb.DelayedMessageCount(ctx)
+}
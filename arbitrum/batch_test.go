@@ -0,0 +1,133 @@
+package arbitrum
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/youngqqcn/arbitrum/common"
+	"github.com/youngqqcn/arbitrum/core/types"
+	"github.com/youngqqcn/arbitrum/rpc"
+)
+
+func TestGetBatchRawData(t *testing.T) {
+	api, blocks := newTestAPIBackend(t, 3, nil)
+	arb := api.b.arb.(*testArbInterface)
+	batch := []byte{0x00, 0x1b, 0x2c, 0x3d}
+	arb.batches = map[uint64][]byte{2: batch, 3: batch}
+
+	for _, block := range blocks[1:] {
+		data, err := api.GetBatchRawData(context.Background(), rpc.BlockNumberOrHashWithHash(block.Hash(), false))
+		if err != nil {
+			t.Fatalf("block %d: failed to get batch data: %v", block.NumberU64(), err)
+		}
+		if !bytes.Equal(data, batch) {
+			t.Errorf("block %d: wrong batch data: have %x, want %x", block.NumberU64(), data, batch)
+		}
+	}
+	if _, err := api.GetBatchRawData(context.Background(), rpc.BlockNumberOrHashWithNumber(1)); err == nil {
+		t.Error("expected error for block without batch")
+	}
+}
+
+func TestGetBlockBatchPosition(t *testing.T) {
+	api, _ := newTestAPIBackend(t, 3, nil)
+	arb := api.b.arb.(*testArbInterface)
+	arb.positions = map[uint64]testBatchPosition{
+		1: {batch: 1, index: 0},
+		2: {batch: 2, index: 0},
+		3: {batch: 2, index: 1},
+	}
+
+	for number, want := range arb.positions {
+		batch, index, err := api.GetBlockBatchPosition(context.Background(), rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(number)))
+		if err != nil {
+			t.Fatalf("block %d: failed to get batch position: %v", number, err)
+		}
+		if batch != want.batch || index != want.index {
+			t.Errorf("block %d: wrong batch position: have %d/%d, want %d/%d", number, batch, index, want.batch, want.index)
+		}
+	}
+	if _, _, err := api.GetBlockBatchPosition(context.Background(), rpc.BlockNumberOrHashWithNumber(0)); err == nil {
+		t.Error("expected error for block without batch")
+	}
+}
+
+func TestGetTransactionSequenceNumber(t *testing.T) {
+	api, _ := newTestAPIBackend(t, 0, nil)
+	tx := signTestTransfer(t, 0)
+	api.b.arb.(*testArbInterface).sequence = map[common.Hash]uint64{tx.Hash(): 42}
+
+	seqNum, err := api.GetTransactionSequenceNumber(context.Background(), tx.Hash())
+	if err != nil {
+		t.Fatalf("failed to get sequence number: %v", err)
+	}
+	if seqNum != 42 {
+		t.Errorf("wrong sequence number: have %d, want 42", seqNum)
+	}
+	if _, err := api.GetTransactionSequenceNumber(context.Background(), common.Hash{1}); !errors.Is(err, ErrTransactionNotSequenced) {
+		t.Errorf("wrong error for unknown tx: have %v, want %v", err, ErrTransactionNotSequenced)
+	}
+}
+
+func TestGetDelayedInboxCount(t *testing.T) {
+	api, _ := newTestAPIBackend(t, 1, nil)
+	arb := api.b.arb.(*testArbInterface)
+	arb.delayed = 42
+
+	count, err := api.GetDelayedInboxCount(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get delayed inbox count: %v", err)
+	}
+	if count != 42 {
+		t.Errorf("wrong delayed inbox count: have %d, want 42", count)
+	}
+
+	// The accumulator only grows as L1 messages arrive
+	prev := count
+	for i := 0; i < 3; i++ {
+		arb.delayed += uint64(i)
+		count, err := api.GetDelayedInboxCount(context.Background())
+		if err != nil {
+			t.Fatalf("failed to get delayed inbox count: %v", err)
+		}
+		if count < prev {
+			t.Errorf("delayed inbox count decreased: have %d, previously %d", count, prev)
+		}
+		prev = count
+	}
+	if prev != 45 {
+		t.Errorf("wrong final delayed inbox count: have %d, want 45", prev)
+	}
+}
+
+func TestGetL1BaseFeePaid(t *testing.T) {
+	api, blocks := newTestAPIBackend(t, 3, nil)
+	arb := api.b.arb.(*testArbInterface)
+	arb.l1Fees = map[uint64]*big.Int{
+		1: big.NewInt(30_000_000_000),
+		2: big.NewInt(31_500_000_000),
+		3: big.NewInt(29_000_000_000),
+	}
+
+	for _, block := range blocks {
+		fee, err := api.GetL1BaseFeePaid(context.Background(), rpc.BlockNumberOrHashWithHash(block.Hash(), false))
+		if err != nil {
+			t.Fatalf("block %d: failed to get L1 base fee: %v", block.NumberU64(), err)
+		}
+		if want := arb.l1Fees[block.NumberU64()]; fee.Cmp(want) != 0 {
+			t.Errorf("block %d: wrong L1 base fee: have %v, want %v", block.NumberU64(), fee, want)
+		}
+	}
+	if _, err := api.GetL1BaseFeePaid(context.Background(), rpc.BlockNumberOrHashWithNumber(0)); err == nil {
+		t.Error("expected error for block without batch")
+	}
+
+	// Blocks from before the Nitro genesis are served by the classic node
+	api.ChainConfig().ArbitrumChainParams.GenesisBlockNum = 2
+	if _, err := api.GetL1BaseFeePaid(context.Background(), rpc.BlockNumberOrHashWithNumber(1)); !errors.Is(err, types.ErrUseFallback) {
+		t.Errorf("wrong error for pre-Nitro block: %v", err)
+	}
+}
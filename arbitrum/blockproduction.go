@@ -0,0 +1,56 @@
+package arbitrum
+
+import (
+	"errors"
+	"sync/atomic"
+
+	"github.com/youngqqcn/arbitrum/log"
+)
+
+var errDangerousDebugCallsDisabled = errors.New("dangerous debug calls are disabled, see allow-dangerous-debug-calls")
+
+// SignalNewBlock asks the sequencer to create a new L2 block unless it'd be empty.
+// It returns whether the signal was forwarded, which it isn't while block production is paused.
+func (b *Backend) SignalNewBlock() bool {
+	if b.BlockProductionPaused() {
+		return false
+	}
+	select {
+	case b.chanNewBlock <- struct{}{}:
+	default:
+		// a signal is already pending
+	}
+	return true
+}
+
+// NewBlockSignals returns the channel the sequencer receives new block signals from
+func (b *Backend) NewBlockSignals() <-chan struct{} {
+	return b.chanNewBlock
+}
+
+// PauseBlockProduction stops forwarding new block signals to the sequencer, e.g. for a maintenance window
+func (b *Backend) PauseBlockProduction() error {
+	if !b.config.AllowDangerousDebugCalls {
+		return errDangerousDebugCallsDisabled
+	}
+	if atomic.CompareAndSwapInt32(&b.blockProductionPaused, 0, 1) {
+		log.Warn("Block production paused")
+	}
+	return nil
+}
+
+// ResumeBlockProduction resumes forwarding new block signals to the sequencer
+func (b *Backend) ResumeBlockProduction() error {
+	if !b.config.AllowDangerousDebugCalls {
+		return errDangerousDebugCallsDisabled
+	}
+	if atomic.CompareAndSwapInt32(&b.blockProductionPaused, 1, 0) {
+		log.Info("Block production resumed")
+	}
+	return nil
+}
+
+// BlockProductionPaused reports whether new block signals are currently dropped
+func (b *Backend) BlockProductionPaused() bool {
+	return atomic.LoadInt32(&b.blockProductionPaused) != 0
+}
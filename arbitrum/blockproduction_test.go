@@ -0,0 +1,75 @@
+package arbitrum
+
+import "testing"
+
+func TestPauseBlockProduction(t *testing.T) {
+	api, _ := newTestAPIBackend(t, 0, nil)
+	b := api.b
+	pending := func() bool {
+		select {
+		case <-b.NewBlockSignals():
+			return true
+		default:
+			return false
+		}
+	}
+
+	if err := b.PauseBlockProduction(); err == nil {
+		t.Fatal("expected pausing to require dangerous debug calls")
+	}
+	b.config.AllowDangerousDebugCalls = true
+
+	if !b.SignalNewBlock() || !pending() {
+		t.Fatal("signal wasn't forwarded before pausing")
+	}
+	if err := b.PauseBlockProduction(); err != nil {
+		t.Fatalf("failed to pause: %v", err)
+	}
+	if !b.BlockProductionPaused() {
+		t.Error("block production not reported as paused")
+	}
+	if b.SignalNewBlock() || pending() {
+		t.Error("signal was forwarded while paused")
+	}
+	if err := b.ResumeBlockProduction(); err != nil {
+		t.Fatalf("failed to resume: %v", err)
+	}
+	if b.BlockProductionPaused() {
+		t.Error("block production still reported as paused")
+	}
+	if !b.SignalNewBlock() || !pending() {
+		t.Error("signal wasn't forwarded after resuming")
+	}
+}
+
+func TestSetHead(t *testing.T) {
+	api, blocks := newTestAPIBackend(t, 5, nil)
+	head := blocks[len(blocks)-1].NumberU64()
+
+	// Refused unless dangerous debug calls are allowed and block production is paused
+	api.SetHead(2)
+	if number := api.CurrentBlock().NumberU64(); number != head {
+		t.Fatalf("head rewound without dangerous debug calls: have %d, want %d", number, head)
+	}
+	api.b.config.AllowDangerousDebugCalls = true
+	api.SetHead(2)
+	if number := api.CurrentBlock().NumberU64(); number != head {
+		t.Fatalf("head rewound while producing blocks: have %d, want %d", number, head)
+	}
+	if err := api.b.PauseBlockProduction(); err != nil {
+		t.Fatalf("failed to pause: %v", err)
+	}
+
+	// Rewinding forward is a no-op
+	api.SetHead(head + 1)
+	if number := api.CurrentBlock().NumberU64(); number != head {
+		t.Fatalf("head moved past the chain: have %d, want %d", number, head)
+	}
+	api.SetHead(2)
+	if number := api.CurrentBlock().NumberU64(); number != 2 {
+		t.Errorf("wrong head after rewind: have %d, want 2", number)
+	}
+	if hash := api.CurrentBlock().Hash(); hash != blocks[1].Hash() {
+		t.Errorf("wrong head block after rewind: have %v, want %v", hash, blocks[1].Hash())
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"time"
 
 	"github.com/youngqqcn/arbitrum/eth"
+	"github.com/youngqqcn/arbitrum/log"
+	"github.com/youngqqcn/arbitrum/metrics"
 )
 
 const (
@@ -22,8 +24,13 @@ const (
 	// bloomRetrievalWait is the maximum time to wait for enough bloom bit requests
 	// to accumulate request an entire batch (avoiding hysteresis).
 	bloomRetrievalWait = time.Duration(0)
+
+	// bloomLagCheckInterval is how often the bloom indexer progress is compared to the chain head.
+	bloomLagCheckInterval = time.Minute
 )
 
+var bloomLagGauge = metrics.NewRegisteredGauge("arb/bloom/lag", nil)
+
 // startBloomHandlers starts a batch of goroutines to accept bloom bit database
 // retrievals from possibly a range of filters and serving the data to satisfy.
 func (b *Backend) startBloomHandlers(sectionSize uint64) {
@@ -44,3 +51,35 @@ func (b *Backend) startBloomHandlers(sectionSize uint64) {
 		}()
 	}
 }
+
+// monitorBloomLag periodically checks that the bloom indexer keeps pace with the chain head.
+func (b *Backend) monitorBloomLag() {
+	ticker := time.NewTicker(bloomLagCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			sections, _, _ := b.bloomIndexer.Sections()
+			b.checkBloomLag(sections)
+		case <-b.chanClose:
+			return
+		}
+	}
+}
+
+// checkBloomLag returns the number of blocks not covered by the given number of indexed
+// bloom sections, and whether that exceeds the configured threshold.
+func (b *Backend) checkBloomLag(sections uint64) (uint64, bool) {
+	head := b.arb.BlockChain().CurrentBlock().NumberU64()
+	indexed := sections * b.config.BloomBitsBlocks
+	var lag uint64
+	if head >= indexed {
+		lag = head + 1 - indexed
+	}
+	bloomLagGauge.Update(int64(lag))
+	if lag > b.config.BloomLagWarnThreshold {
+		log.Warn("Bloom indexer is lagging behind the chain head, log queries will be slow", "head", head, "indexed", indexed, "lag", lag)
+		return lag, true
+	}
+	return lag, false
+}
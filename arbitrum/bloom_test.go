@@ -0,0 +1,89 @@
+package arbitrum
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/youngqqcn/arbitrum/common"
+	"github.com/youngqqcn/arbitrum/core/bloombits"
+	"github.com/youngqqcn/arbitrum/log"
+)
+
+func TestCheckBloomLag(t *testing.T) {
+	api, _ := newTestAPIBackend(t, 20, nil)
+	api.b.config.BloomBitsBlocks = 4
+	api.b.config.BloomLagWarnThreshold = 8
+
+	var warnings int
+	defer func(h log.Handler) { log.Root().SetHandler(h) }(log.Root().GetHandler())
+	log.Root().SetHandler(log.FuncHandler(func(r *log.Record) error {
+		if r.Lvl == log.LvlWarn {
+			warnings++
+		}
+		return nil
+	}))
+
+	// 4 sections cover blocks 0-15, leaving 16-20 unindexed
+	if lag, lagging := api.b.checkBloomLag(4); lagging || lag != 5 {
+		t.Errorf("wrong result for keeping up indexer: have (%d, %v), want (5, false)", lag, lagging)
+	}
+	if warnings != 0 {
+		t.Errorf("unexpected warnings: %d", warnings)
+	}
+	// 1 section covers blocks 0-3, leaving 4-20 unindexed
+	if lag, lagging := api.b.checkBloomLag(1); !lagging || lag != 17 {
+		t.Errorf("wrong result for lagging indexer: have (%d, %v), want (17, true)", lag, lagging)
+	}
+	if warnings != 1 {
+		t.Errorf("wrong number of warnings: have %d, want 1", warnings)
+	}
+}
+
+func TestServiceFilterConcurrencyLimit(t *testing.T) {
+	api, _ := newTestAPIBackend(t, 1, nil)
+	const limit = 4
+	api.b.bloomRetrievalSlots = make(chan struct{}, limit)
+	// The test backend doesn't service bloom requests, so retrievals stay pending until their session is closed
+
+	const count = 10
+	sessions := make([]*bloombits.MatcherSession, count)
+	serving := make(chan int, count)
+	for i := 0; i < count; i++ {
+		matcher := bloombits.NewMatcher(api.b.config.BloomBitsBlocks, [][][]byte{{common.Address{byte(i)}.Bytes()}})
+		session, err := matcher.Start(context.Background(), 0, api.b.config.BloomBitsBlocks-1, make(chan uint64, 1))
+		if err != nil {
+			t.Fatalf("session %d: failed to start matcher: %v", i, err)
+		}
+		sessions[i] = session
+		go func(i int) {
+			api.ServiceFilter(context.Background(), sessions[i])
+			serving <- i
+		}(i)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if busy := len(api.b.bloomRetrievalSlots); busy != limit {
+		t.Errorf("wrong number of retrieval goroutines: have %d, want %d", busy, limit)
+	}
+	if started := len(serving); started == 0 || started > limit {
+		t.Errorf("wrong number of serviced sessions: have %d, want between 1 and %d", started, limit)
+	}
+
+	// Closing the serviced sessions frees their slots for the waiting ones
+	for served := 0; served < count; served++ {
+		select {
+		case i := <-serving:
+			sessions[i].Close()
+		case <-time.After(time.Second):
+			t.Fatalf("only %d of %d sessions serviced", served, count)
+		}
+		if busy := len(api.b.bloomRetrievalSlots); busy > limit {
+			t.Fatalf("retrieval goroutines exceed the limit: have %d, want at most %d", busy, limit)
+		}
+	}
+	for start := time.Now(); len(api.b.bloomRetrievalSlots) > 0; time.Sleep(10 * time.Millisecond) {
+		if time.Since(start) > time.Second {
+			t.Fatalf("retrieval slots not freed: %d still busy", len(api.b.bloomRetrievalSlots))
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package arbitrum
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/youngqqcn/arbitrum/core"
+	"github.com/youngqqcn/arbitrum/core/types"
+	"github.com/youngqqcn/arbitrum/core/vm"
+	"github.com/youngqqcn/arbitrum/rpc"
+)
+
+// CallWithBlockOverrides executes a call like eth_call, with the block context's fields (number, timestamp, base fee, ...)
+// replaced by the given overrides, e.g. to simulate execution in a future block
+func (a *APIBackend) CallWithBlockOverrides(ctx context.Context, args TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides *StateOverride, blockOverrides *BlockOverrides) (*core.ExecutionResult, error) {
+	state, header, err := a.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	if err := overrides.Apply(state); err != nil {
+		return nil, err
+	}
+	timeout := a.RPCEVMTimeout()
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	msg, err := args.ToMessage(a.RPCGasCap(), header, state, types.MessageEthcallMode)
+	if err != nil {
+		return nil, err
+	}
+	// Arbitrum: support NodeInterface.sol by swapping out the message if needed
+	var res *core.ExecutionResult
+	msg, res, err = core.InterceptRPCMessage(msg, ctx, state, header, a)
+	if err != nil || res != nil {
+		return res, err
+	}
+
+	blockCtx := core.NewEVMBlockContext(header, a.blockChain(), nil)
+	blockOverrides.Apply(&blockCtx)
+	vmConfig := *a.blockChain().GetVMConfig()
+	vmConfig.NoBaseFee = true
+	evm := vm.NewEVM(blockCtx, core.NewEVMTxContext(msg), state, a.ChainConfig(), vmConfig)
+	go func() {
+		<-ctx.Done()
+		evm.Cancel()
+	}()
+
+	result, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(math.MaxUint64))
+	if evm.Cancelled() {
+		return nil, fmt.Errorf("execution aborted (timeout = %v)", timeout)
+	}
+	if err != nil {
+		return result, fmt.Errorf("err: %w (supplied gas %d)", err, msg.Gas())
+	}
+	return result, nil
+}
+
+// IntrinsicGas returns the gas a transaction is charged before any execution (base cost, calldata and access list),
+// under the chain rules active at the given block
+func (a *APIBackend) IntrinsicGas(ctx context.Context, args TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash) (uint64, error) {
+	header, err := a.HeaderByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return 0, err
+	}
+	if header == nil {
+		return 0, errors.New("header not found")
+	}
+	arbosVersion := types.DeserializeHeaderExtraInformation(header).ArbOSFormatVersion
+	rules := a.ChainConfig().Rules(header.Number, false, header.Time, arbosVersion)
+
+	var accessList types.AccessList
+	if args.AccessList != nil {
+		accessList = *args.AccessList
+	}
+	return core.IntrinsicGas(txArgsData(args), accessList, args.To == nil, rules.IsHomestead, rules.IsIstanbul, rules.IsShanghai)
+}
+
+// txArgsData returns the calldata of the transaction arguments, preferring input over data like the RPC does
+func txArgsData(args TransactionArgs) []byte {
+	if args.Input != nil {
+		return *args.Input
+	}
+	if args.Data != nil {
+		return *args.Data
+	}
+	return nil
+}
+
+// EstimateTotalFee returns the expected cost of a transaction in wei: the estimated gas at the block's base fee.
+// The estimate already includes the gas ArbOS charges for posting the transaction to L1, so no separate L1 term is added
+func (a *APIBackend) EstimateTotalFee(ctx context.Context, args TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash) (*big.Int, error) {
+	header, err := a.HeaderByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, errors.New("header not found")
+	}
+	gas, err := EstimateGas(ctx, a, args, blockNrOrHash, a.RPCGasCap())
+	if err != nil {
+		return nil, err
+	}
+	total := new(big.Int)
+	if header.BaseFee != nil {
+		total.Mul(header.BaseFee, new(big.Int).SetUint64(uint64(gas)))
+	}
+	return total, nil
+}
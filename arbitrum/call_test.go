@@ -0,0 +1,177 @@
+package arbitrum
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/youngqqcn/arbitrum/common"
+	"github.com/youngqqcn/arbitrum/common/hexutil"
+	"github.com/youngqqcn/arbitrum/core"
+	"github.com/youngqqcn/arbitrum/core/state"
+	"github.com/youngqqcn/arbitrum/core/types"
+	"github.com/youngqqcn/arbitrum/core/vm"
+	"github.com/youngqqcn/arbitrum/params"
+	"github.com/youngqqcn/arbitrum/rpc"
+)
+
+func TestCallWithBlockOverrides(t *testing.T) {
+	// returns block.timestamp
+	clock := common.HexToAddress("0x000000000000000000000000000000000000c10c")
+	alloc := core.GenesisAlloc{
+		clock: {Balance: common.Big0, Code: common.FromHex("0x4260005260206000f3")},
+	}
+	api, _ := newTestAPIBackendWithAlloc(t, alloc, 1, nil)
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	args := TransactionArgs{From: &testAddr, To: &clock}
+
+	result, err := api.CallWithBlockOverrides(context.Background(), args, latest, nil, nil)
+	if err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if have, want := new(big.Int).SetBytes(result.ReturnData).Uint64(), api.CurrentHeader().Time; have != want {
+		t.Errorf("wrong timestamp without override: have %d, want %d", have, want)
+	}
+
+	future := hexutil.Uint64(api.CurrentHeader().Time + 3600)
+	result, err = api.CallWithBlockOverrides(context.Background(), args, latest, nil, &BlockOverrides{Time: &future})
+	if err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if have := new(big.Int).SetBytes(result.ReturnData).Uint64(); have != uint64(future) {
+		t.Errorf("wrong timestamp with override: have %d, want %d", have, future)
+	}
+}
+
+func TestIntrinsicGas(t *testing.T) {
+	api, _ := newTestAPIBackend(t, 1, nil)
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	to := common.Address{0xaa}
+
+	transfer, err := api.IntrinsicGas(context.Background(), TransactionArgs{From: &testAddr, To: &to}, latest)
+	if err != nil {
+		t.Fatalf("failed to compute intrinsic gas: %v", err)
+	}
+	if transfer != params.TxGas {
+		t.Errorf("wrong transfer intrinsic gas: have %d, want %d", transfer, params.TxGas)
+	}
+
+	data := hexutil.Bytes(bytes.Repeat([]byte{0xff}, 100))
+	call, err := api.IntrinsicGas(context.Background(), TransactionArgs{From: &testAddr, To: &to, Input: &data}, latest)
+	if err != nil {
+		t.Fatalf("failed to compute intrinsic gas: %v", err)
+	}
+	if want := params.TxGas + 100*params.TxDataNonZeroGasEIP2028; call != want {
+		t.Errorf("wrong call intrinsic gas: have %d, want %d", call, want)
+	}
+}
+
+func TestEstimateTotalFee(t *testing.T) {
+	posterGas := setTestPosterGas(t)
+	api, _ := newTestAPIBackend(t, 1, nil)
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	to := common.Address{0xaa}
+	baseFee := api.CurrentHeader().BaseFee
+
+	data := hexutil.Bytes(bytes.Repeat([]byte{0xff}, 1000))
+	tests := []struct {
+		name string
+		args TransactionArgs
+		gas  uint64
+	}{
+		{"transfer", TransactionArgs{From: &testAddr, To: &to, Value: (*hexutil.Big)(big.NewInt(1000))}, params.TxGas},
+		{"calldata", TransactionArgs{From: &testAddr, To: &to, Input: &data}, params.TxGas + uint64(len(data))*params.TxDataNonZeroGasEIP2028 + posterGas(data)},
+	}
+	for _, tt := range tests {
+		total, err := api.EstimateTotalFee(context.Background(), tt.args, latest)
+		if err != nil {
+			t.Fatalf("%s: failed to estimate total fee: %v", tt.name, err)
+		}
+		// The L1 posting cost is part of the gas estimate, so it must be paid exactly once
+		want := new(big.Int).Mul(baseFee, new(big.Int).SetUint64(tt.gas))
+		if total.Cmp(want) != 0 {
+			t.Errorf("%s: wrong total fee: have %v, want %v", tt.name, total, want)
+		}
+	}
+}
+
+// setTestPosterGas charges every transaction an L1 posting cost proportional to its calldata, the way ArbOS does
+// through its processing hook and the RPC gas cap, and returns the cost function
+func setTestPosterGas(t *testing.T) func(data []byte) uint64 {
+	posterGas := func(data []byte) uint64 { return params.TxDataNonZeroGasEIP2028 * uint64(len(data)) }
+	readyEVMForL2, interceptRPCGasCap := core.ReadyEVMForL2, core.InterceptRPCGasCap
+	t.Cleanup(func() {
+		core.ReadyEVMForL2, core.InterceptRPCGasCap = readyEVMForL2, interceptRPCGasCap
+	})
+	core.ReadyEVMForL2 = func(evm *vm.EVM, msg core.Message) {
+		if _, ok := evm.ProcessingHook.(*testPosterHook); !ok {
+			evm.ProcessingHook = &testPosterHook{TxProcessingHook: evm.ProcessingHook, posterGas: posterGas(msg.Data())}
+		}
+	}
+	core.InterceptRPCGasCap = func(gascap *uint64, msg types.Message, header *types.Header, statedb *state.StateDB) {
+		if *gascap != 0 {
+			*gascap += posterGas(msg.Data())
+		}
+	}
+	return posterGas
+}
+
+// testPosterHook charges a transaction's L1 posting cost upfront, like ArbOS does
+type testPosterHook struct {
+	vm.TxProcessingHook
+	posterGas uint64
+}
+
+func (h *testPosterHook) GasChargingHook(gasRemaining *uint64) (common.Address, error) {
+	if *gasRemaining < h.posterGas {
+		return common.Address{}, core.ErrIntrinsicGas
+	}
+	*gasRemaining -= h.posterGas
+	return h.TxProcessingHook.GasChargingHook(gasRemaining)
+}
+
+func (h *testPosterHook) FillReceiptInfo(receipt *types.Receipt) {
+	receipt.GasUsedForL1 = h.posterGas
+}
+
+func TestEstimateGasWithOptions(t *testing.T) {
+	posterGas := setTestPosterGas(t)
+	api, _ := newTestAPIBackend(t, 1, nil)
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	to := common.Address{0xaa}
+	data := hexutil.Bytes(bytes.Repeat([]byte{0xff}, 1000))
+	args := TransactionArgs{From: &testAddr, To: &to, Input: &data}
+
+	inclusive, err := EstimateGasWithOptions(context.Background(), api, args, latest, api.RPCGasCap(), EstimateGasOptions{})
+	if err != nil {
+		t.Fatalf("failed to estimate L1-inclusive gas: %v", err)
+	}
+	exclusive, err := EstimateGasWithOptions(context.Background(), api, args, latest, api.RPCGasCap(), EstimateGasOptions{ExcludeL1Gas: true})
+	if err != nil {
+		t.Fatalf("failed to estimate L1-exclusive gas: %v", err)
+	}
+	intrinsic := params.TxGas + posterGas(data)
+	if uint64(exclusive) != intrinsic {
+		t.Errorf("wrong L1-exclusive estimate: have %d, want %d", exclusive, intrinsic)
+	}
+	if uint64(inclusive-exclusive) != posterGas(data) {
+		t.Errorf("estimates differ by %d, want the L1 gas used %d", inclusive-exclusive, posterGas(data))
+	}
+	plain, err := EstimateGas(context.Background(), api, args, latest, api.RPCGasCap())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain != inclusive {
+		t.Errorf("plain estimate %d differs from the L1-inclusive one %d", plain, inclusive)
+	}
+
+	limit := EstimateGasOptions{MaxGas: uint64(exclusive)}
+	if _, err := EstimateGasWithOptions(context.Background(), api, args, latest, api.RPCGasCap(), limit); err == nil {
+		t.Error("L1-inclusive estimate above the limit accepted")
+	}
+	limit.ExcludeL1Gas = true
+	if _, err := EstimateGasWithOptions(context.Background(), api, args, latest, api.RPCGasCap(), limit); err != nil {
+		t.Errorf("L1-exclusive estimate within the limit rejected: %v", err)
+	}
+}
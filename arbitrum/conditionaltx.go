@@ -3,6 +3,7 @@ package arbitrum
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/youngqqcn/arbitrum/arbitrum_types"
 	"github.com/youngqqcn/arbitrum/common"
@@ -30,6 +31,25 @@ func (s *ArbTransactionAPI) SendRawTransactionConditional(ctx context.Context, i
 	return SubmitConditionalTransaction(ctx, s.b, tx, options)
 }
 
+// checkConditionalTxSender rejects conditional transactions from senders missing from ConditionalTxAllowedSenders, if set
+func (a *APIBackend) checkConditionalTxSender(tx *types.Transaction) error {
+	allowed := a.b.config.ConditionalTxAllowedSenders
+	if len(allowed) == 0 {
+		return nil
+	}
+	signer := types.MakeSigner(a.ChainConfig(), a.CurrentBlock().Number())
+	from, err := types.Sender(signer, tx)
+	if err != nil {
+		return err
+	}
+	for _, sender := range allowed {
+		if common.HexToAddress(sender) == from {
+			return nil
+		}
+	}
+	return arbitrum_types.NewRejectedError(fmt.Sprintf("sender %v not allowed to submit conditional transactions", from))
+}
+
 func SubmitConditionalTransaction(ctx context.Context, b *APIBackend, tx *types.Transaction, options *arbitrum_types.ConditionalOptions) (common.Hash, error) {
 	// If the transaction fee cap is already specified, ensure the
 	// fee of the given transaction is _reasonable_.
@@ -59,6 +79,57 @@ func SubmitConditionalTransaction(ctx context.Context, b *APIBackend, tx *types.
 	return tx.Hash(), nil
 }
 
+// ConditionalTxSubmission is a signed transaction to submit along with the conditions it may be sequenced under
+type ConditionalTxSubmission struct {
+	Tx      *types.Transaction
+	Options *arbitrum_types.ConditionalOptions
+}
+
+// ConditionalTxResult is the outcome of one transaction of a conditional batch: its hash if it was accepted, or why it wasn't
+type ConditionalTxResult struct {
+	Hash  *common.Hash `json:"hash,omitempty"`
+	Error string       `json:"error,omitempty"`
+	Code  int          `json:"code,omitempty"`
+}
+
+func newConditionalTxResult(hash common.Hash, err error) ConditionalTxResult {
+	if err != nil {
+		result := ConditionalTxResult{Error: err.Error()}
+		var rpcErr rpc.Error
+		if errors.As(err, &rpcErr) {
+			result.Code = rpcErr.ErrorCode()
+		}
+		return result
+	}
+	return ConditionalTxResult{Hash: &hash}
+}
+
+// SendConditionalTxBatch submits conditional transactions in order, each checked against its own options,
+// saving relayers a round-trip per transaction. A rejected transaction doesn't abort the rest of the batch;
+// the result at each index reports how the transaction at that index fared.
+// Batches larger than ConditionalTxMaxBatchSize are rejected as a whole.
+func (a *APIBackend) SendConditionalTxBatch(ctx context.Context, submissions []ConditionalTxSubmission) ([]ConditionalTxResult, error) {
+	if err := a.checkConditionalBatchSize(len(submissions)); err != nil {
+		return nil, err
+	}
+	results := make([]ConditionalTxResult, len(submissions))
+	for i, submission := range submissions {
+		if err := ctx.Err(); err != nil {
+			results[i] = newConditionalTxResult(common.Hash{}, err)
+			continue
+		}
+		results[i] = newConditionalTxResult(SubmitConditionalTransaction(ctx, a, submission.Tx, submission.Options))
+	}
+	return results, nil
+}
+
+func (a *APIBackend) checkConditionalBatchSize(size int) error {
+	if limit := a.b.config.ConditionalTxMaxBatchSize; limit > 0 && uint64(size) > limit {
+		return arbitrum_types.NewLimitExceededError(fmt.Sprintf("batch of %d conditional transactions, the limit is %d", size, limit))
+	}
+	return nil
+}
+
 func SendConditionalTransactionRPC(ctx context.Context, rpc *rpc.Client, tx *types.Transaction, options *arbitrum_types.ConditionalOptions) error {
 	data, err := tx.MarshalBinary()
 	if err != nil {
@@ -66,3 +137,56 @@ func SendConditionalTransactionRPC(ctx context.Context, rpc *rpc.Client, tx *typ
 	}
 	return rpc.CallContext(ctx, nil, "eth_sendRawTransactionConditional", hexutil.Encode(data), options)
 }
+
+// ConditionalCheckAudit is the result of re-checking a conditional transaction's options against the state it was included at
+type ConditionalCheckAudit struct {
+	TxHash      common.Hash                        `json:"txHash"`
+	BlockHash   common.Hash                        `json:"blockHash"`
+	BlockNumber hexutil.Uint64                     `json:"blockNumber"`
+	Options     *arbitrum_types.ConditionalOptions `json:"options"`
+	Passed      bool                               `json:"passed"`
+	Error       string                             `json:"error,omitempty"`
+}
+
+// GetConditionalCheckAudit re-runs the options a conditional transaction was submitted with against the state right before it executed.
+// A failing check means the transaction was sequenced although its conditions didn't hold.
+// Only the options of transactions recently accepted by this node are known.
+func (a *APIBackend) GetConditionalCheckAudit(ctx context.Context, txHash common.Hash) (*ConditionalCheckAudit, error) {
+	options, ok := a.b.conditionalOptions.Get(txHash)
+	if !ok {
+		return nil, errors.New("no conditional options known for transaction")
+	}
+	tx, blockHash, blockNumber, index, err := a.GetTransaction(ctx, txHash)
+	if err != nil {
+		return nil, err
+	}
+	if tx == nil {
+		return nil, errors.New("transaction not included yet")
+	}
+	block, err := a.BlockByHash(ctx, blockHash)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block %d not found", blockNumber)
+	}
+	_, _, statedb, release, err := a.StateAtTransaction(ctx, block, int(index), defaultTraceReexec)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	audit := &ConditionalCheckAudit{
+		TxHash:      txHash,
+		BlockHash:   blockHash,
+		BlockNumber: hexutil.Uint64(blockNumber),
+		Options:     options,
+		Passed:      true,
+	}
+	l1BlockNumber := types.DeserializeHeaderExtraInformation(block.Header()).L1BlockNumber
+	if err := options.CheckWithContext(ctx, l1BlockNumber, block.Time(), statedb); err != nil {
+		audit.Passed = false
+		audit.Error = err.Error()
+	}
+	return audit, nil
+}
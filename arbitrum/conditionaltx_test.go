@@ -0,0 +1,207 @@
+package arbitrum
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/youngqqcn/arbitrum/arbitrum_types"
+	"github.com/youngqqcn/arbitrum/common"
+	"github.com/youngqqcn/arbitrum/common/hexutil"
+	"github.com/youngqqcn/arbitrum/consensus/ethash"
+	"github.com/youngqqcn/arbitrum/core"
+	"github.com/youngqqcn/arbitrum/core/types"
+	"github.com/youngqqcn/arbitrum/params"
+	"github.com/youngqqcn/arbitrum/rpc"
+)
+
+func TestGetConditionalCheckAudit(t *testing.T) {
+	api, _ := newTestAPIBackend(t, 1, nil)
+
+	var txs []*types.Transaction
+	signer := types.LatestSigner(api.ChainConfig())
+	blocks, _ := core.GenerateChain(api.ChainConfig(), api.CurrentBlock(), ethash.NewFaker(), api.ChainDb(), 1, func(i int, b *core.BlockGen) {
+		for nonce := uint64(0); nonce < 3; nonce++ {
+			tx, err := types.SignTx(types.NewTransaction(nonce, common.Address{0xaa}, big.NewInt(1000), params.TxGas, b.BaseFee(), nil), signer, testKey)
+			if err != nil {
+				t.Fatalf("failed to sign tx: %v", err)
+			}
+			b.AddTx(tx)
+			txs = append(txs, tx)
+		}
+	})
+	if _, err := api.blockChain().InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	inclusionTime := blocks[0].Time()
+
+	// The first transaction's condition held at inclusion, the second one's didn't
+	held := hexutil.Uint64(inclusionTime)
+	expired := hexutil.Uint64(inclusionTime - 1)
+	if err := api.SendConditionalTx(context.Background(), txs[0], &arbitrum_types.ConditionalOptions{TimestampMax: &held}); err != nil {
+		t.Fatalf("failed to send tx: %v", err)
+	}
+	if err := api.SendConditionalTx(context.Background(), txs[1], &arbitrum_types.ConditionalOptions{TimestampMax: &expired}); err != nil {
+		t.Fatalf("failed to send tx: %v", err)
+	}
+	if err := api.SendTx(context.Background(), txs[2]); err != nil {
+		t.Fatalf("failed to send tx: %v", err)
+	}
+
+	audit, err := api.GetConditionalCheckAudit(context.Background(), txs[0].Hash())
+	if err != nil {
+		t.Fatalf("failed to audit tx: %v", err)
+	}
+	if !audit.Passed || audit.BlockHash != blocks[0].Hash() {
+		t.Errorf("wrong audit for held condition: %+v", audit)
+	}
+	audit, err = api.GetConditionalCheckAudit(context.Background(), txs[1].Hash())
+	if err != nil {
+		t.Fatalf("failed to audit tx: %v", err)
+	}
+	if audit.Passed || audit.Error == "" {
+		t.Errorf("violated condition not reported: %+v", audit)
+	}
+	if _, err := api.GetConditionalCheckAudit(context.Background(), txs[2].Hash()); err == nil {
+		t.Error("expected error for transaction without conditional options")
+	}
+}
+
+func TestConditionalRequiredTxHashes(t *testing.T) {
+	api, blocks := newTestAPIBackend(t, 1, transferGenerator(t, 1))
+	included := blocks[len(blocks)-1].Transactions()[0].Hash()
+
+	statedb, header, err := api.StateAndHeaderByNumber(context.Background(), rpc.LatestBlockNumber)
+	if err != nil {
+		t.Fatalf("failed to get state: %v", err)
+	}
+	options := &arbitrum_types.ConditionalOptions{RequiredTxHashes: []common.Hash{included}}
+	if err := options.Check(0, header.Time, statedb); err != nil {
+		t.Errorf("rejected with required tx included: %v", err)
+	}
+	options.RequiredTxHashes = append(options.RequiredTxHashes, common.Hash{0xde, 0xad})
+	if err := options.Check(0, header.Time, statedb); err == nil {
+		t.Error("accepted with required tx missing")
+	}
+}
+
+func TestConditionalTxAllowedSenders(t *testing.T) {
+	api, _ := newTestAPIBackend(t, 1, nil)
+	arb := api.b.arb.(*testArbInterface)
+	options := &arbitrum_types.ConditionalOptions{}
+
+	// Anyone may submit by default
+	if err := api.SendConditionalTx(context.Background(), signTestTransfer(t, 0), options); err != nil {
+		t.Fatalf("rejected without allowlist: %v", err)
+	}
+
+	api.b.config.ConditionalTxAllowedSenders = []string{common.Address{0xbb}.Hex(), testAddr.Hex()}
+	if err := api.SendConditionalTx(context.Background(), signTestTransfer(t, 1), options); err != nil {
+		t.Fatalf("rejected allowed sender: %v", err)
+	}
+
+	api.b.config.ConditionalTxAllowedSenders = []string{common.Address{0xbb}.Hex()}
+	err := api.SendConditionalTx(context.Background(), signTestTransfer(t, 2), options)
+	if rpcErr, ok := err.(interface{ ErrorCode() int }); !ok || rpcErr.ErrorCode() != -32003 {
+		t.Errorf("wrong error for disallowed sender: %v", err)
+	}
+	api.b.config.ConditionalTxAllowedSenders = []string{"not an address"}
+	if err := api.b.config.Validate(); err == nil {
+		t.Error("invalid allowed sender accepted")
+	}
+	if len(arb.published) != 2 {
+		t.Errorf("wrong number of published transactions: have %d, want 2", len(arb.published))
+	}
+}
+
+func TestConditionalTxLimits(t *testing.T) {
+	api, _ := newTestAPIBackend(t, 1, nil)
+	arb := api.b.arb.(*testArbInterface)
+	api.b.config.ConditionalTxMaxConditions = 1
+
+	options := &arbitrum_types.ConditionalOptions{RequiredTxHashes: []common.Hash{{0x01}, {0x02}}}
+	err := api.SendConditionalTx(context.Background(), signTestTransfer(t, 0), options)
+	if rpcErr, ok := err.(interface{ ErrorCode() int }); !ok || rpcErr.ErrorCode() != -32005 {
+		t.Errorf("wrong error for too many conditions: %v", err)
+	}
+	if len(arb.published) != 0 {
+		t.Errorf("transaction over the limits published")
+	}
+}
+
+func TestSendConditionalTxBatch(t *testing.T) {
+	api, _ := newTestAPIBackend(t, 1, nil)
+	arb := api.b.arb.(*testArbInterface)
+	arb.checkConditions = true
+
+	head := api.CurrentHeader().Time
+	future := hexutil.Uint64(head + 100)
+	past := hexutil.Uint64(head - 1)
+	results, err := api.SendConditionalTxBatch(context.Background(), []ConditionalTxSubmission{
+		{Tx: signTestTransfer(t, 0), Options: &arbitrum_types.ConditionalOptions{TimestampMax: &future}},
+		{Tx: signTestTransfer(t, 1), Options: &arbitrum_types.ConditionalOptions{TimestampMax: &past}},
+		{Tx: signTestTransfer(t, 2), Options: nil},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3", len(results))
+	}
+	if results[0].Hash == nil || results[0].Error != "" {
+		t.Errorf("first transaction rejected: %+v", results[0])
+	}
+	if results[1].Hash != nil || results[1].Error == "" || results[1].Code != -32003 {
+		t.Errorf("violated condition not reported: %+v", results[1])
+	}
+	if results[2].Hash == nil || *results[2].Hash != signTestTransfer(t, 2).Hash() {
+		t.Errorf("transaction after a rejected one not submitted: %+v", results[2])
+	}
+	if len(arb.published) != 2 || arb.published[0].Nonce() != 0 || arb.published[1].Nonce() != 2 {
+		t.Errorf("wrong transactions published: %v", arb.published)
+	}
+
+	// Over RPC, undecodable transactions are reported at their index too
+	valid, err := signTestTransfer(t, 3).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err = NewArbAPI(api).SendRawTransactionConditionalBatch(context.Background(), []RawConditionalTransaction{
+		{Tx: hexutil.Bytes{0xde, 0xad}},
+		{Tx: valid, Options: &arbitrum_types.ConditionalOptions{TimestampMin: &past}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Error == "" || results[1].Hash == nil {
+		t.Errorf("wrong RPC batch results: %+v", results)
+	}
+	if len(arb.published) != 3 {
+		t.Errorf("wrong number of published transactions: have %d, want 3", len(arb.published))
+	}
+}
+
+func TestConditionalTxBatchSizeLimit(t *testing.T) {
+	api, _ := newTestAPIBackend(t, 1, nil)
+	arb := api.b.arb.(*testArbInterface)
+	api.b.config.ConditionalTxMaxBatchSize = 2
+
+	submissions := []ConditionalTxSubmission{{Tx: signTestTransfer(t, 0)}, {Tx: signTestTransfer(t, 1)}, {Tx: signTestTransfer(t, 2)}}
+	var rpcErr rpc.Error
+	if _, err := api.SendConditionalTxBatch(context.Background(), submissions); !errors.As(err, &rpcErr) || rpcErr.ErrorCode() != -32005 {
+		t.Errorf("oversized batch not rejected: %v", err)
+	}
+	raw := make([]RawConditionalTransaction, 3)
+	if _, err := NewArbAPI(api).SendRawTransactionConditionalBatch(context.Background(), raw); !errors.As(err, &rpcErr) || rpcErr.ErrorCode() != -32005 {
+		t.Errorf("oversized RPC batch not rejected: %v", err)
+	}
+	if len(arb.published) != 0 {
+		t.Errorf("transactions of an oversized batch published: %v", arb.published)
+	}
+
+	results, err := api.SendConditionalTxBatch(context.Background(), submissions[:2])
+	if err != nil || len(results) != 2 {
+		t.Errorf("batch within the limit rejected: %v", err)
+	}
+}
@@ -1,11 +1,15 @@
 package arbitrum
 
 import (
+	"fmt"
 	"time"
 
 	flag "github.com/spf13/pflag"
+	"github.com/youngqqcn/arbitrum/arbitrum_types"
+	"github.com/youngqqcn/arbitrum/common"
 	"github.com/youngqqcn/arbitrum/eth/ethconfig"
 	"github.com/youngqqcn/arbitrum/params"
+	"github.com/youngqqcn/arbitrum/rpc"
 )
 
 type Config struct {
@@ -23,17 +27,153 @@ type Config struct {
 	BloomBitsBlocks uint64 `koanf:"bloom-bits-blocks"`
 	BloomConfirms   uint64 `koanf:"bloom-confirms"`
 
+	// BloomLagWarnThreshold is the number of unindexed blocks behind the head above which the
+	// bloom indexer is reported as lagging (0 = don't monitor)
+	BloomLagWarnThreshold uint64 `koanf:"bloom-lag-warn-threshold"`
+
+	// MaxBloomRetrievalGoroutines bounds the bloom retrieval goroutines across all log filters (0 = no limit)
+	MaxBloomRetrievalGoroutines int `koanf:"max-bloom-retrieval-goroutines"`
+
 	// Parameters for the filter system
 	FilterLogCacheSize int           `koanf:"filter-log-cache-size"`
 	FilterTimeout      time.Duration `koanf:"filter-timeout"`
 
+	// MaxFilterRange is the max number of blocks a log query may span (0 = no limit)
+	MaxFilterRange uint64 `koanf:"max-filter-range"`
+
+	// LogsPageSize is the max number of logs returned per page by GetLogsPaged
+	LogsPageSize int `koanf:"logs-page-size"`
+
+	// PendingLogsBehavior selects what pending log subscriptions receive: "alias" delivers the confirmed logs,
+	// "empty" never fires and "projected" delivers the logs of the block being produced
+	PendingLogsBehavior string `koanf:"pending-logs-behavior"`
+
+	// PendingBlockMaxTxs limits the number of in-flight transactions executed to predict the pending block (0 = no limit)
+	PendingBlockMaxTxs int `koanf:"pending-block-max-txs"`
+
+	// SubscriptionQueueLimit is the max number of events queued for a slow subscriber of the backend's own feeds
+	// (full blocks, chain from a block, base fee changes, sequencer health, deep reorgs, all logs) before it's unsubscribed (0 = no limit)
+	SubscriptionQueueLimit int `koanf:"subscription-queue-limit"`
+
+	// ReceiptsMaxBlockCount limits the number of blocks a single GetReceiptsForBlocks request may cover (0 = no limit)
+	ReceiptsMaxBlockCount uint64 `koanf:"receipts-max-block-count"`
+
 	// FeeHistoryMaxBlockCount limits the number of historical blocks a fee history request may cover
 	FeeHistoryMaxBlockCount uint64 `koanf:"feehistory-max-block-count"`
 
+	// FeeHistoryCacheSize is the number of fee history windows kept to answer repeated requests (0 = no cache)
+	FeeHistoryCacheSize int `koanf:"feehistory-cache-size"`
+
+	// FeeHistoryOmitProjected drops the predicted next-block base fee, so exactly blockCount base fees are returned
+	FeeHistoryOmitProjected bool `koanf:"feehistory-omit-projected"`
+
+	// FeeHistoryEmptyRewards returns a reward entry with no percentiles for each block when no reward
+	// percentiles are requested, instead of omitting the rewards altogether
+	FeeHistoryEmptyRewards bool `koanf:"feehistory-empty-rewards"`
+
+	// MinGasTip is the priority fee in wei suggested to clients (tips have no effect on inclusion)
+	MinGasTip uint64 `koanf:"min-gas-tip"`
+
+	// ReportTotalDifficulty controls whether GetTd computes a total difficulty.
+	// Arbitrum blocks have a trivial difficulty, so the total is meaningless for chain comparison;
+	// when disabled, GetTd returns nil to signal it's not applicable.
+	ReportTotalDifficulty bool `koanf:"report-total-difficulty"`
+
+	// SafeFinalizedDefaultToGenesis resolves the safe and finalized block tags to the genesis block
+	// while the node doesn't know them yet, instead of returning ErrBlockNotYetAvailable
+	SafeFinalizedDefaultToGenesis bool `koanf:"safe-finalized-default-to-genesis"`
+
+	// ExpensiveMethodRateLimits limits how often a single remote IP may call eth_getLogs, eth_call and debug_trace*
+	ExpensiveMethodRateLimits RateLimitConfig `koanf:"expensive-method-rate-limits"`
+
+	// DefaultBlockParam is the block tag or number used when a request doesn't specify a block
+	DefaultBlockParam string `koanf:"default-block-param"`
+
+	// StateAtBlockMaxRewind bounds how many blocks StateAtBlock may re-execute to recreate a missing state (0 = no bound)
+	StateAtBlockMaxRewind uint64 `koanf:"state-at-block-max-rewind"`
+
+	// AllowDangerousDebugCalls enables operations that can disrupt the node, like pausing block production
+	AllowDangerousDebugCalls bool `koanf:"allow-dangerous-debug-calls"`
+
+	// EnabledNamespaces lists the RPC namespaces served by the node (empty = all).
+	// The filter API is part of the eth namespace.
+	EnabledNamespaces []string `koanf:"enabled-namespaces"`
+
 	ArbDebug ArbDebugConfig `koanf:"arbdebug"`
 
 	ClassicRedirect        string        `koanf:"classic-redirect"`
 	ClassicRedirectTimeout time.Duration `koanf:"classic-redirect-timeout"`
+
+	// FallbackClientRetries is the number of times a classic redirect failing with a transient error or timeout is retried
+	FallbackClientRetries    uint64        `koanf:"classic-redirect-retries"`
+	FallbackClientRetryDelay time.Duration `koanf:"classic-redirect-retry-delay"`
+	FallbackClientMetrics    bool          `koanf:"classic-redirect-metrics"`
+
+	ConditionalTxMaxConditions int `koanf:"conditional-tx-max-conditions"`
+	ConditionalTxMaxSlots      int `koanf:"conditional-tx-max-slots"`
+	// ConditionalTxMaxBatchSize bounds the number of transactions in a conditional batch (0 = no limit).
+	ConditionalTxMaxBatchSize uint64 `koanf:"conditional-tx-max-batch-size"`
+	// ConditionalTxAllowedSenders lists the addresses that may submit conditional transactions (empty = anyone).
+	ConditionalTxAllowedSenders []string `koanf:"conditional-tx-allowed-senders"`
+}
+
+// knownNamespaces are the RPC namespaces the backend registers
+var knownNamespaces = []string{"eth", "net", "txpool", "arb", "debug", "personal"}
+
+// Validate checks the config for values the backend can't run with
+func (c *Config) Validate() error {
+	for _, namespace := range c.EnabledNamespaces {
+		known := false
+		for _, name := range knownNamespaces {
+			if namespace == name {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("unknown RPC namespace %q, expected one of %v", namespace, knownNamespaces)
+		}
+	}
+	for _, sender := range c.ConditionalTxAllowedSenders {
+		if !common.IsHexAddress(sender) {
+			return fmt.Errorf("invalid conditional transaction sender %q", sender)
+		}
+	}
+	switch c.PendingLogsBehavior {
+	case "", PendingLogsAlias, PendingLogsEmpty, PendingLogsProjected:
+		// An unset behavior aliases the confirmed logs
+	default:
+		return fmt.Errorf("unknown pending logs behavior %q, expected %q, %q or %q", c.PendingLogsBehavior, PendingLogsAlias, PendingLogsEmpty, PendingLogsProjected)
+	}
+	if _, err := c.defaultBlockNumber(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// defaultBlockNumber parses DefaultBlockParam, treating an empty value as latest
+func (c *Config) defaultBlockNumber() (rpc.BlockNumber, error) {
+	if c.DefaultBlockParam == "" {
+		return rpc.LatestBlockNumber, nil
+	}
+	var number rpc.BlockNumber
+	if err := number.UnmarshalJSON([]byte(c.DefaultBlockParam)); err != nil {
+		return 0, fmt.Errorf("invalid default block parameter %q: %w", c.DefaultBlockParam, err)
+	}
+	return number, nil
+}
+
+// ConditionalOptionsLimits returns the limits conditional transactions submitted over RPC are checked against
+func (c *Config) ConditionalOptionsLimits() arbitrum_types.ConditionalOptionsLimits {
+	return arbitrum_types.ConditionalOptionsLimits{
+		MaxConditions: c.ConditionalTxMaxConditions,
+		MaxSlots:      c.ConditionalTxMaxSlots,
+	}
+}
+
+type RateLimitConfig struct {
+	Requests uint64        `koanf:"requests"`
+	Window   time.Duration `koanf:"window"`
 }
 
 type ArbDebugConfig struct {
@@ -46,11 +186,42 @@ func ConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.Float64(prefix+".tx-fee-cap", DefaultConfig.RPCTxFeeCap, "cap on transaction fee (in ether) that can be sent via the RPC APIs (0 = no cap)")
 	f.Duration(prefix+".evm-timeout", DefaultConfig.RPCEVMTimeout, "timeout used for eth_call (0=infinite)")
 	f.Uint64(prefix+".bloom-bits-blocks", DefaultConfig.BloomBitsBlocks, "number of blocks a single bloom bit section vector holds")
+	f.Uint64(prefix+".bloom-lag-warn-threshold", DefaultConfig.BloomLagWarnThreshold, "number of unindexed blocks behind the head above which the bloom indexer is reported as lagging (0 = don't monitor)")
+	f.Int(prefix+".max-bloom-retrieval-goroutines", DefaultConfig.MaxBloomRetrievalGoroutines, "maximum number of goroutines retrieving bloom bits for all log filters together (0 = no limit)")
+	f.Uint64(prefix+".receipts-max-block-count", DefaultConfig.ReceiptsMaxBlockCount, "max number of blocks whose receipts may be requested at once (0 = no limit)")
 	f.Uint64(prefix+".feehistory-max-block-count", DefaultConfig.FeeHistoryMaxBlockCount, "max number of blocks a fee history request may cover")
-	f.String(prefix+".classic-redirect", DefaultConfig.ClassicRedirect, "url to redirect classic requests, use \"error:[CODE:]MESSAGE\" to return specified error instead of redirecting")
+	f.Int(prefix+".feehistory-cache-size", DefaultConfig.FeeHistoryCacheSize, "number of fee history windows cached for repeated requests (0 = no cache)")
+	f.Bool(prefix+".feehistory-omit-projected", DefaultConfig.FeeHistoryOmitProjected, "omit the predicted next block base fee from fee history responses")
+	f.Bool(prefix+".feehistory-empty-rewards", DefaultConfig.FeeHistoryEmptyRewards, "return an empty reward list per block rather than no rewards when fee history is requested without reward percentiles")
+	f.Uint64(prefix+".min-gas-tip", DefaultConfig.MinGasTip, "priority fee in wei suggested by eth_maxPriorityFeePerGas (tips have no effect on inclusion)")
+	f.Bool(prefix+".report-total-difficulty", DefaultConfig.ReportTotalDifficulty, "report a total difficulty for blocks (Arbitrum blocks have trivial difficulty)")
+	f.Bool(prefix+".safe-finalized-default-to-genesis", DefaultConfig.SafeFinalizedDefaultToGenesis, "resolve the safe and finalized block tags to the genesis block while they're not yet available")
+	f.String(prefix+".classic-redirect", DefaultConfig.ClassicRedirect, "url to redirect classic requests (several comma separated urls are load balanced), use \"error:[CODE:]MESSAGE\" to return specified error instead of redirecting")
 	f.Duration(prefix+".classic-redirect-timeout", DefaultConfig.ClassicRedirectTimeout, "timeout for forwarded classic requests, where 0 = no timeout")
+	f.Uint64(prefix+".classic-redirect-retries", DefaultConfig.FallbackClientRetries, "number of times a classic request failing with a connection error or timing out is retried")
+	f.Duration(prefix+".classic-redirect-retry-delay", DefaultConfig.FallbackClientRetryDelay, "delay before the first retry of a classic request, doubled for each further retry")
+	f.Bool(prefix+".classic-redirect-metrics", DefaultConfig.FallbackClientMetrics, "record per method metrics of the calls, errors and latency of classic requests")
+	f.Int(prefix+".conditional-tx-max-conditions", DefaultConfig.ConditionalTxMaxConditions, "maximum number of accounts and transactions the conditions of a conditional transaction refer to, where 0 = no limit")
+	f.Int(prefix+".conditional-tx-max-slots", DefaultConfig.ConditionalTxMaxSlots, "maximum total number of storage slots in the knownAccounts condition of a conditional transaction, where 0 = no limit")
+	f.Uint64(prefix+".conditional-tx-max-batch-size", DefaultConfig.ConditionalTxMaxBatchSize, "max number of transactions in a conditional transaction batch (0 = no limit)")
+	f.StringSlice(prefix+".conditional-tx-allowed-senders", DefaultConfig.ConditionalTxAllowedSenders, "addresses allowed to submit conditional transactions (empty = anyone)")
 	f.Int(prefix+".filter-log-cache-size", DefaultConfig.FilterLogCacheSize, "log filter system maximum number of cached blocks")
 	f.Duration(prefix+".filter-timeout", DefaultConfig.FilterTimeout, "log filter system maximum time filters stay active")
+	f.Uint64(prefix+".max-filter-range", DefaultConfig.MaxFilterRange, "max number of blocks a log query may span (0 = no limit)")
+	f.Int(prefix+".logs-page-size", DefaultConfig.LogsPageSize, "max number of logs returned per page of a paginated logs query")
+	f.Int(prefix+".pending-block-max-txs", DefaultConfig.PendingBlockMaxTxs, "max number of in-flight transactions executed to predict the pending block (0 = no limit)")
+	f.Int(prefix+".subscription-queue-limit", DefaultConfig.SubscriptionQueueLimit, "max number of events queued for a slow subscriber before it's unsubscribed (0 = no limit)")
+	f.String(prefix+".pending-logs-behavior", DefaultConfig.PendingLogsBehavior, "what pending log subscriptions receive: \"alias\" (confirmed logs), \"empty\" (nothing) or \"projected\" (logs of the block being produced)")
+
+	f.String(prefix+".default-block-param", DefaultConfig.DefaultBlockParam, "block tag or number used when a request doesn't specify a block (e.g. latest, safe, finalized)")
+	f.Uint64(prefix+".state-at-block-max-rewind", DefaultConfig.StateAtBlockMaxRewind, "max number of blocks re-executed to recreate a missing historical state (0 = no limit)")
+	f.Bool(prefix+".allow-dangerous-debug-calls", DefaultConfig.AllowDangerousDebugCalls, "allow debug operations that can disrupt the node, like pausing block production")
+
+	f.StringSlice(prefix+".enabled-namespaces", DefaultConfig.EnabledNamespaces, "RPC namespaces to serve, out of eth, net, txpool, arb, debug and personal (empty = all)")
+
+	rateLimits := DefaultConfig.ExpensiveMethodRateLimits
+	f.Uint64(prefix+".expensive-method-rate-limits.requests", rateLimits.Requests, "number of expensive calls (eth_getLogs, eth_call, debug_trace*) a single IP may make per window (0 = unlimited)")
+	f.Duration(prefix+".expensive-method-rate-limits.window", rateLimits.Window, "window over which expensive calls are counted per IP")
 
 	arbDebug := DefaultConfig.ArbDebug
 	f.Uint64(prefix+".arbdebug.block-range-bound", arbDebug.BlockRangeBound, "bounds the number of blocks arbdebug calls may return")
@@ -63,10 +234,43 @@ var DefaultConfig = Config{
 	RPCEVMTimeout:           ethconfig.Defaults.RPCEVMTimeout, // 5 seconds
 	BloomBitsBlocks:         params.BloomBitsBlocks * 4,       // we generally have smaller blocks
 	BloomConfirms:           params.BloomConfirms,
+	BloomLagWarnThreshold:   params.BloomBitsBlocks * 8, // two sections
 	FilterLogCacheSize:      32,
 	FilterTimeout:           5 * time.Minute,
+	MaxFilterRange:          1_000_000,
+	LogsPageSize:            1000,
+	PendingLogsBehavior:     PendingLogsAlias,
+	PendingBlockMaxTxs:      1000,
+	SubscriptionQueueLimit:  1024,
+	ReceiptsMaxBlockCount:   256,
 	FeeHistoryMaxBlockCount: 1024,
+	FeeHistoryCacheSize:     128,
+	FeeHistoryOmitProjected: false,
+	FeeHistoryEmptyRewards:  false,
+	MinGasTip:               0,
 	ClassicRedirect:         "",
+
+	FallbackClientRetries:    0,
+	FallbackClientRetryDelay: 100 * time.Millisecond,
+	FallbackClientMetrics:    false,
+
+	ConditionalTxMaxConditions:  1000,
+	ConditionalTxMaxSlots:       10000,
+	ConditionalTxMaxBatchSize:   100,
+	ConditionalTxAllowedSenders: nil,
+
+	MaxBloomRetrievalGoroutines: 0,
+
+	ReportTotalDifficulty:         true,
+	SafeFinalizedDefaultToGenesis: false,
+	DefaultBlockParam:             "latest",
+	StateAtBlockMaxRewind:         0,
+	AllowDangerousDebugCalls:      false,
+	EnabledNamespaces:             nil,
+	ExpensiveMethodRateLimits: RateLimitConfig{
+		Requests: 0,
+		Window:   time.Minute,
+	},
 	ArbDebug: ArbDebugConfig{
 		BlockRangeBound:   256,
 		TimeoutQueueBound: 512,
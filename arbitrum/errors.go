@@ -0,0 +1,34 @@
+package arbitrum
+
+import (
+	"errors"
+
+	"github.com/youngqqcn/arbitrum/arbitrum_types"
+	"github.com/youngqqcn/arbitrum/core"
+)
+
+// ErrSequencerQueueFull should be returned (or wrapped) by PublishTransaction when the sequencer can't accept more transactions
+var ErrSequencerQueueFull = errors.New("sequencer queue full")
+
+// PublishErrorMapper translates an error returned by PublishTransaction into the error reported to RPC clients
+type PublishErrorMapper func(error) error
+
+// MapPublishError is the default PublishErrorMapper.
+// It turns the known sequencer rejections into standard JSON-RPC errors with clean messages,
+// keeping the original error as their cause so errors.Is still matches it, and leaves any other error untouched.
+func MapPublishError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, core.ErrNonceTooLow):
+		return arbitrum_types.NewRejectedErrorWithCause(core.ErrNonceTooLow.Error(), err)
+	case errors.Is(err, core.ErrNonceTooHigh):
+		return arbitrum_types.NewRejectedErrorWithCause(core.ErrNonceTooHigh.Error(), err)
+	case errors.Is(err, core.ErrFeeCapTooLow):
+		return arbitrum_types.NewRejectedErrorWithCause(core.ErrFeeCapTooLow.Error(), err)
+	case errors.Is(err, ErrSequencerQueueFull):
+		return arbitrum_types.NewLimitExceededErrorWithCause("sequencer queue full, retry later", err)
+	default:
+		return err
+	}
+}
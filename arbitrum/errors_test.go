@@ -0,0 +1,56 @@
+package arbitrum
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/youngqqcn/arbitrum/core"
+	"github.com/youngqqcn/arbitrum/rpc"
+)
+
+func TestMapPublishError(t *testing.T) {
+	otherErr := errors.New("some other error")
+	tests := []struct {
+		err      error
+		wantCode int
+		wantMsg  string
+	}{
+		{fmt.Errorf("%w: address 0x01, tx: 1 state: 2", core.ErrNonceTooLow), -32003, "nonce too low"},
+		{fmt.Errorf("%w: address 0x01, tx: 5 state: 2", core.ErrNonceTooHigh), -32003, "nonce too high"},
+		{fmt.Errorf("%w: address 0x01, maxFeePerGas: 1 baseFee: 2", core.ErrFeeCapTooLow), -32003, "max fee per gas less than block base fee"},
+		{fmt.Errorf("failed to enqueue: %w", ErrSequencerQueueFull), -32005, "sequencer queue full, retry later"},
+	}
+	for _, tt := range tests {
+		mapped := MapPublishError(tt.err)
+		var rpcErr rpc.Error
+		if !errors.As(mapped, &rpcErr) {
+			t.Errorf("%v: mapped error %T is not an rpc error", tt.err, mapped)
+			continue
+		}
+		if rpcErr.ErrorCode() != tt.wantCode {
+			t.Errorf("%v: wrong error code: have %d, want %d", tt.err, rpcErr.ErrorCode(), tt.wantCode)
+		}
+		if mapped.Error() != tt.wantMsg {
+			t.Errorf("%v: wrong message: have %q, want %q", tt.err, mapped.Error(), tt.wantMsg)
+		}
+		if !errors.Is(mapped, errors.Unwrap(tt.err)) || !errors.Is(mapped, tt.err) {
+			t.Errorf("%v: mapped error lost its cause", tt.err)
+		}
+	}
+	if mapped := MapPublishError(otherErr); mapped != otherErr {
+		t.Errorf("unknown error was mapped: %v", mapped)
+	}
+}
+
+func TestSendTxMapsPublishErrors(t *testing.T) {
+	api, _ := newTestAPIBackend(t, 0, nil)
+	api.b.arb.(*testArbInterface).publishErr = ErrSequencerQueueFull
+
+	err := api.SendTx(context.Background(), signTestTransfer(t, 0))
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) || rpcErr.ErrorCode() != -32005 {
+		t.Errorf("wrong error: have %v", err)
+	}
+}
@@ -2,19 +2,82 @@ package arbitrum
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/youngqqcn/arbitrum/common/hexutil"
 	"github.com/youngqqcn/arbitrum/core"
+	"github.com/youngqqcn/arbitrum/core/types"
 	"github.com/youngqqcn/arbitrum/internal/ethapi"
 	"github.com/youngqqcn/arbitrum/rpc"
 )
 
 type TransactionArgs = ethapi.TransactionArgs
+type StateOverride = ethapi.StateOverride
+type BlockOverrides = ethapi.BlockOverrides
 
 func EstimateGas(ctx context.Context, b ethapi.Backend, args TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, gasCap uint64) (hexutil.Uint64, error) {
 	return ethapi.DoEstimateGas(ctx, b, args, blockNrOrHash, gasCap)
 }
 
+// EstimateGasOptions adjusts the result of EstimateGasWithOptions
+type EstimateGasOptions struct {
+	// MaxGas is an upper bound on the returned estimate, zero for none.
+	MaxGas uint64
+	// ExcludeL1Gas removes the gas paying for posting the transaction's data to L1 from the estimate.
+	ExcludeL1Gas bool
+}
+
+// EstimateGasWithOptions is like EstimateGas, but post-processes the estimate according to the given options.
+//
+// The plain estimate is L1-inclusive: it is the gas limit the transaction needs, which covers both the L2
+// execution and the L1 posting cost charged upfront, converted to L2 gas at the given block's L1 and L2 prices.
+// As L1 prices move, the L1 component of an inclusive estimate goes stale, while the L2 component doesn't.
+// The L1-exclusive estimate is the L2 execution gas alone; it is not a usable gas limit by itself.
+func EstimateGasWithOptions(ctx context.Context, b ethapi.Backend, args TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, gasCap uint64, options EstimateGasOptions) (hexutil.Uint64, error) {
+	estimate, err := ethapi.DoEstimateGas(ctx, b, args, blockNrOrHash, gasCap)
+	if err != nil {
+		return 0, err
+	}
+	if options.ExcludeL1Gas {
+		l1Gas, err := l1GasComponent(ctx, b, args, blockNrOrHash, uint64(estimate))
+		if err != nil {
+			return 0, err
+		}
+		estimate -= hexutil.Uint64(l1Gas)
+	}
+	if options.MaxGas != 0 && uint64(estimate) > options.MaxGas {
+		return 0, fmt.Errorf("gas required (%d) exceeds the limit of %d", estimate, options.MaxGas)
+	}
+	return estimate, nil
+}
+
+// l1GasComponent returns how much of an estimate pays for posting the transaction to L1,
+// which is how much ArbOS raises a gas cap equal to the estimate to leave it for L2 execution alone.
+func l1GasComponent(ctx context.Context, b ethapi.Backend, args TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, estimate uint64) (uint64, error) {
+	statedb, header, err := b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return 0, err
+	}
+	if statedb == nil || header == nil {
+		return 0, errors.New("state not found")
+	}
+	gas := hexutil.Uint64(estimate)
+	args.Gas = &gas
+	l2OnlyCap, err := args.L2OnlyGasCap(estimate, header, statedb, types.MessageGasEstimationMode)
+	if err != nil {
+		return 0, err
+	}
+	if l2OnlyCap < estimate {
+		return 0, nil
+	}
+	l1Gas := l2OnlyCap - estimate
+	if l1Gas > estimate {
+		l1Gas = estimate
+	}
+	return l1Gas, nil
+}
+
 func NewRevertReason(result *core.ExecutionResult) error {
 	return ethapi.NewRevertError(result)
 }
@@ -0,0 +1,198 @@
+package arbitrum
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/youngqqcn/arbitrum/core/types"
+	"github.com/youngqqcn/arbitrum/log"
+	"github.com/youngqqcn/arbitrum/metrics"
+	"github.com/youngqqcn/arbitrum/rpc"
+)
+
+// retryingFallbackClient retries calls to the classic fallback that failed with a transient error,
+// waiting an exponentially growing, jittered delay between attempts
+type retryingFallbackClient struct {
+	impl    types.FallbackClient
+	retries uint64
+	delay   time.Duration
+}
+
+func newRetryingFallbackClient(impl types.FallbackClient, retries uint64, delay time.Duration) types.FallbackClient {
+	if impl == nil || retries == 0 {
+		return impl
+	}
+	return &retryingFallbackClient{impl: impl, retries: retries, delay: delay}
+}
+
+func (c *retryingFallbackClient) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	var err error
+	for attempt := uint64(0); ; attempt++ {
+		err = c.impl.CallContext(ctx, result, method, args...)
+		if err == nil || attempt >= c.retries || !isTransientFallbackError(ctx, err) {
+			return err
+		}
+		delay := c.backoff(attempt)
+		log.Debug("Retrying fallback call", withRequestID(ctx, "method", method, "attempt", attempt+1, "delay", delay, "err", err)...)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
+
+// backoff returns the delay before the retry following the given attempt, doubling per attempt,
+// with half of it randomized so clients don't retry in lockstep
+func (c *retryingFallbackClient) backoff(attempt uint64) time.Duration {
+	if attempt > 16 {
+		attempt = 16
+	}
+	delay := c.delay << attempt
+	if delay <= 1 {
+		return delay
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)))
+}
+
+// isTransientFallbackError reports whether a fallback call failed at the connection level,
+// rather than with a well-formed JSON-RPC error response. A deadline hit while the caller's
+// context is still live is the endpoint's own timeout, and counts as transient.
+func isTransientFallbackError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode >= 500
+	}
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+const (
+	// fallbackEndpointFailureThreshold is the number of consecutive transient failures after which an endpoint is skipped
+	fallbackEndpointFailureThreshold = 3
+
+	// fallbackEndpointCooldown is how long an unhealthy endpoint is skipped before it's tried again
+	fallbackEndpointCooldown = 30 * time.Second
+)
+
+type fallbackEndpoint struct {
+	client         types.FallbackClient
+	failures       int
+	unhealthyUntil time.Time
+}
+
+// roundRobinFallbackClient spreads calls across several classic nodes, moving on to the next endpoint
+// when one fails with a transient error, and skipping endpoints that failed repeatedly for a cooldown
+type roundRobinFallbackClient struct {
+	mu        sync.Mutex
+	endpoints []*fallbackEndpoint
+	next      int
+	now       func() time.Time
+}
+
+func newRoundRobinFallbackClient(clients []types.FallbackClient) *roundRobinFallbackClient {
+	endpoints := make([]*fallbackEndpoint, len(clients))
+	for i, client := range clients {
+		endpoints[i] = &fallbackEndpoint{client: client}
+	}
+	return &roundRobinFallbackClient{endpoints: endpoints, now: time.Now}
+}
+
+// order returns the endpoints to try for a call, starting at the next one in turn.
+// Unhealthy endpoints are only tried after all healthy ones.
+func (c *roundRobinFallbackClient) order() []*fallbackEndpoint {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	var healthy, unhealthy []*fallbackEndpoint
+	for i := range c.endpoints {
+		endpoint := c.endpoints[(c.next+i)%len(c.endpoints)]
+		if now.Before(endpoint.unhealthyUntil) {
+			unhealthy = append(unhealthy, endpoint)
+		} else {
+			healthy = append(healthy, endpoint)
+		}
+	}
+	c.next = (c.next + 1) % len(c.endpoints)
+	return append(healthy, unhealthy...)
+}
+
+// report updates the health of an endpoint after a call. Only a success resets the failure count;
+// well-formed error responses leave it unchanged.
+func (c *roundRobinFallbackClient) report(ctx context.Context, endpoint *fallbackEndpoint, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err == nil {
+		endpoint.failures = 0
+		endpoint.unhealthyUntil = time.Time{}
+		return
+	}
+	if !isTransientFallbackError(ctx, err) {
+		return
+	}
+	endpoint.failures++
+	if endpoint.failures >= fallbackEndpointFailureThreshold {
+		endpoint.unhealthyUntil = c.now().Add(fallbackEndpointCooldown)
+	}
+}
+
+func (c *roundRobinFallbackClient) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	var err error
+	for _, endpoint := range c.order() {
+		err = endpoint.client.CallContext(ctx, result, method, args...)
+		c.report(ctx, endpoint, err)
+		if err == nil || !isTransientFallbackError(ctx, err) {
+			return err
+		}
+	}
+	return err
+}
+
+// fallbackMetricsPrefix is the prefix of the per-method classic fallback metrics
+const fallbackMetricsPrefix = "arb/fallback"
+
+// meteredFallbackClient counts the calls to the classic fallback and their errors,
+// and records their latency, per RPC method
+type meteredFallbackClient struct {
+	impl types.FallbackClient
+}
+
+func newMeteredFallbackClient(impl types.FallbackClient) types.FallbackClient {
+	if impl == nil {
+		return nil
+	}
+	return &meteredFallbackClient{impl: impl}
+}
+
+func (c *meteredFallbackClient) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	start := time.Now()
+	err := c.impl.CallContext(ctx, result, method, args...)
+	metrics.GetOrRegisterCounter(fmt.Sprintf("%s/%s/calls", fallbackMetricsPrefix, method), nil).Inc(1)
+	if err != nil {
+		metrics.GetOrRegisterCounter(fmt.Sprintf("%s/%s/errors", fallbackMetricsPrefix, method), nil).Inc(1)
+	}
+	sampler := func() metrics.Sample {
+		return metrics.NewBoundedHistogramSample()
+	}
+	metrics.GetOrRegisterHistogramLazy(fmt.Sprintf("%s/%s/duration", fallbackMetricsPrefix, method), nil, sampler).Update(time.Since(start).Microseconds())
+	return err
+}
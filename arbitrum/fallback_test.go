@@ -0,0 +1,201 @@
+package arbitrum
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/youngqqcn/arbitrum/core/types"
+	"github.com/youngqqcn/arbitrum/metrics"
+	"github.com/youngqqcn/arbitrum/rpc"
+)
+
+// testFallbackClient fails the first failures calls with err, then succeeds
+type testFallbackClient struct {
+	failures int
+	err      error
+	calls    int
+}
+
+func (c *testFallbackClient) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	c.calls++
+	if c.calls <= c.failures {
+		return c.err
+	}
+	return nil
+}
+
+type testRPCError struct{}
+
+func (testRPCError) Error() string  { return "execution reverted" }
+func (testRPCError) ErrorCode() int { return 3 }
+
+func TestRetryingFallbackClient(t *testing.T) {
+	tests := []struct {
+		name      string
+		failures  int
+		err       error
+		wantCalls int
+		wantErr   bool
+	}{
+		{"transient", 2, io.ErrUnexpectedEOF, 3, false},
+		{"server error", 1, rpc.HTTPError{StatusCode: 502, Status: "502 Bad Gateway"}, 2, false},
+		{"retries exhausted", 5, io.EOF, 4, true},
+		{"rpc error", 1, testRPCError{}, 1, true},
+		{"client error", 1, rpc.HTTPError{StatusCode: 400, Status: "400 Bad Request"}, 1, true},
+	}
+	for _, tt := range tests {
+		impl := &testFallbackClient{failures: tt.failures, err: tt.err}
+		client := newRetryingFallbackClient(impl, 3, time.Millisecond)
+		err := client.CallContext(context.Background(), nil, "eth_getBalance")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: wrong error: have %v, want error %v", tt.name, err, tt.wantErr)
+		}
+		if impl.calls != tt.wantCalls {
+			t.Errorf("%s: wrong number of calls: have %d, want %d", tt.name, impl.calls, tt.wantCalls)
+		}
+	}
+
+	if client := newRetryingFallbackClient(&testFallbackClient{}, 0, time.Millisecond); client == nil {
+		t.Fatal("client dropped without retries")
+	} else if _, ok := client.(*retryingFallbackClient); ok {
+		t.Error("retrying client created without retries")
+	}
+}
+
+func TestRoundRobinFallbackClient(t *testing.T) {
+	dead := &testFallbackClient{failures: 1000, err: io.EOF}
+	alive := &testFallbackClient{}
+	client := newRoundRobinFallbackClient([]types.FallbackClient{dead, alive})
+	now := time.Now()
+	client.now = func() time.Time { return now }
+
+	for i := 0; i < 10; i++ {
+		if err := client.CallContext(context.Background(), nil, "eth_getBalance"); err != nil {
+			t.Fatalf("call %d failed: %v", i, err)
+		}
+	}
+	if dead.calls != fallbackEndpointFailureThreshold {
+		t.Errorf("dead endpoint not skipped: have %d calls, want %d", dead.calls, fallbackEndpointFailureThreshold)
+	}
+	if alive.calls != 10 {
+		t.Errorf("wrong number of calls to healthy endpoint: have %d, want 10", alive.calls)
+	}
+
+	// Once the cooldown passed, the recovered endpoint takes its turn again
+	dead.failures = dead.calls
+	now = now.Add(fallbackEndpointCooldown)
+	for i := 0; i < 2; i++ {
+		if err := client.CallContext(context.Background(), nil, "eth_getBalance"); err != nil {
+			t.Fatalf("call %d after cooldown failed: %v", i, err)
+		}
+	}
+	if dead.calls != fallbackEndpointFailureThreshold+1 {
+		t.Errorf("recovered endpoint not used: have %d calls, want %d", dead.calls, fallbackEndpointFailureThreshold+1)
+	}
+	if alive.calls != 11 {
+		t.Errorf("calls not spread after recovery: have %d calls to the other endpoint, want 11", alive.calls)
+	}
+
+	// Well-formed errors don't fail over
+	failing := &testFallbackClient{failures: 1, err: testRPCError{}}
+	other := &testFallbackClient{}
+	client = newRoundRobinFallbackClient([]types.FallbackClient{failing, other})
+	if err := client.CallContext(context.Background(), nil, "eth_call"); err == nil {
+		t.Error("expected rpc error to be returned")
+	}
+	if other.calls != 0 {
+		t.Error("rpc error failed over to another endpoint")
+	}
+
+	// Well-formed errors don't reset the failure count either, only successes do
+	flaky := &testFallbackClient{}
+	client = newRoundRobinFallbackClient([]types.FallbackClient{flaky})
+	endpoint := client.endpoints[0]
+	client.report(context.Background(), endpoint, io.EOF)
+	client.report(context.Background(), endpoint, testRPCError{})
+	if endpoint.failures != 1 {
+		t.Errorf("rpc error changed the failure count: have %d, want 1", endpoint.failures)
+	}
+	client.report(context.Background(), endpoint, nil)
+	if endpoint.failures != 0 {
+		t.Errorf("success didn't reset the failure count: have %d", endpoint.failures)
+	}
+}
+
+// hangingFallbackClient never answers, returning only once the call's context is done
+type hangingFallbackClient struct {
+	calls int
+}
+
+func (c *hangingFallbackClient) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	c.calls++
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestFallbackClientTimeout(t *testing.T) {
+	// A hanging endpoint times out and the call fails over to the next one
+	hanging := &hangingFallbackClient{}
+	alive := &testFallbackClient{}
+	client := newRoundRobinFallbackClient([]types.FallbackClient{
+		&timeoutFallbackClient{impl: hanging, timeout: 10 * time.Millisecond},
+		alive,
+	})
+	for i := 0; i < 2*fallbackEndpointFailureThreshold; i++ {
+		if err := client.CallContext(context.Background(), nil, "eth_getBalance"); err != nil {
+			t.Fatalf("call %d failed: %v", i, err)
+		}
+	}
+	if hanging.calls != fallbackEndpointFailureThreshold {
+		t.Errorf("hanging endpoint not skipped: have %d calls, want %d", hanging.calls, fallbackEndpointFailureThreshold)
+	}
+
+	// The endpoint's own timeout is retried
+	hanging = &hangingFallbackClient{}
+	retrying := newRetryingFallbackClient(&timeoutFallbackClient{impl: hanging, timeout: 10 * time.Millisecond}, 2, time.Millisecond)
+	if err := retrying.CallContext(context.Background(), nil, "eth_getBalance"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("wrong error: have %v, want %v", err, context.DeadlineExceeded)
+	}
+	if hanging.calls != 3 {
+		t.Errorf("endpoint timeout not retried: have %d calls, want 3", hanging.calls)
+	}
+
+	// The caller's own deadline isn't
+	hanging = &hangingFallbackClient{}
+	retrying = newRetryingFallbackClient(hanging, 2, time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := retrying.CallContext(ctx, nil, "eth_getBalance"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("wrong error: have %v, want %v", err, context.DeadlineExceeded)
+	}
+	if hanging.calls != 1 {
+		t.Errorf("caller deadline retried: have %d calls, want 1", hanging.calls)
+	}
+}
+
+func TestMeteredFallbackClient(t *testing.T) {
+	enabled := metrics.Enabled
+	metrics.Enabled = true
+	defer func() { metrics.Enabled = enabled }()
+
+	client := newMeteredFallbackClient(&testFallbackClient{failures: 1, err: io.EOF})
+	for i := 0; i < 3; i++ {
+		_ = client.CallContext(context.Background(), nil, "test_metered")
+	}
+	if calls := metrics.GetOrRegisterCounter(fallbackMetricsPrefix+"/test_metered/calls", nil).Count(); calls != 3 {
+		t.Errorf("wrong call count: have %d, want 3", calls)
+	}
+	if errs := metrics.GetOrRegisterCounter(fallbackMetricsPrefix+"/test_metered/errors", nil).Count(); errs != 1 {
+		t.Errorf("wrong error count: have %d, want 1", errs)
+	}
+	duration := metrics.GetOrRegisterHistogramLazy(fallbackMetricsPrefix+"/test_metered/duration", nil, metrics.NewBoundedHistogramSample)
+	if samples := duration.Count(); samples != 3 {
+		t.Errorf("wrong latency sample count: have %d, want 3", samples)
+	}
+	if newMeteredFallbackClient(nil) != nil {
+		t.Error("metered client created without a fallback client")
+	}
+}
@@ -0,0 +1,230 @@
+package arbitrum
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/youngqqcn/arbitrum/arbitrum_types"
+	"github.com/youngqqcn/arbitrum/common"
+	"github.com/youngqqcn/arbitrum/common/lru"
+	"github.com/youngqqcn/arbitrum/core/types"
+	"github.com/youngqqcn/arbitrum/params"
+	"github.com/youngqqcn/arbitrum/rpc"
+)
+
+// feeHistoryCacheKey identifies a fee history window. The newest block's hash guards against reorgs.
+type feeHistoryCacheKey struct {
+	oldestBlock uint64
+	newestHash  common.Hash
+	speedLimit  uint64
+}
+
+// feeHistoryCacheEntry holds the computed fullness analogues and basefees (including the predicted one) of a window,
+// and the tips paid in its blocks once rewards were requested for it
+type feeHistoryCacheEntry struct {
+	gasUsed  []float64
+	basefees []*big.Int
+	tips     []*blockTips
+}
+
+func newFeeHistoryCache(size int) *lru.Cache[feeHistoryCacheKey, *feeHistoryCacheEntry] {
+	if size <= 0 {
+		return nil
+	}
+	return lru.NewCache[feeHistoryCacheKey, *feeHistoryCacheEntry](size)
+}
+
+// ExtendedFeeHistoryResult is the result of FeeHistory, along with the gas prices actually paid in each block
+type ExtendedFeeHistoryResult struct {
+	OldestBlock  *big.Int
+	Reward       [][]*big.Int
+	BaseFee      []*big.Int
+	GasUsedRatio []float64
+
+	// MedianEffectiveGasPrice is the gas weighted median of the effective gas prices paid in each block, as their receipts
+	// report them, or nil for blocks where no gas was paid for
+	MedianEffectiveGasPrice []*big.Int
+}
+
+// ExtendedFeeHistory is like FeeHistory, but also reports the median effective gas price paid in each block
+func (a *APIBackend) ExtendedFeeHistory(ctx context.Context, blocks int, newestBlock rpc.BlockNumber, rewardPercentiles []float64) (*ExtendedFeeHistoryResult, error) {
+	oldest, rewards, baseFees, gasUsed, err := a.FeeHistory(ctx, blocks, newestBlock, rewardPercentiles)
+	if err != nil {
+		return nil, err
+	}
+	result := &ExtendedFeeHistoryResult{
+		OldestBlock:             oldest,
+		Reward:                  rewards,
+		BaseFee:                 baseFees,
+		GasUsedRatio:            gasUsed,
+		MedianEffectiveGasPrice: make([]*big.Int, len(gasUsed)),
+	}
+	for i := range gasUsed {
+		block, receipts, err := a.blockAndReceipts(ctx, rpc.BlockNumber(oldest.Int64()+int64(i)))
+		if err != nil {
+			return nil, err
+		}
+		result.MedianEffectiveGasPrice[i] = medianEffectiveGasPrice(a.ChainConfig(), block, receipts)
+	}
+	return result, nil
+}
+
+// blockAndReceipts returns a block along with the receipts of all its transactions
+func (a *APIBackend) blockAndReceipts(ctx context.Context, number rpc.BlockNumber) (*types.Block, types.Receipts, error) {
+	block, err := a.BlockByNumber(ctx, number)
+	if err != nil {
+		return nil, nil, err
+	}
+	if block == nil {
+		return nil, nil, errors.New("block not found")
+	}
+	receipts := a.blockChain().GetReceiptsByHash(block.Hash())
+	if len(receipts) != len(block.Transactions()) {
+		return nil, nil, errors.New("receipts not found")
+	}
+	return block, receipts, nil
+}
+
+// GetL1GasUsedHistory returns the gas each block in [fromBlock, toBlock] used to pay for posting its transactions to L1.
+// The number of blocks per request is bounded by ReceiptsMaxBlockCount.
+func (a *APIBackend) GetL1GasUsedHistory(ctx context.Context, fromBlock, toBlock uint64) ([]uint64, error) {
+	if fromBlock > toBlock {
+		return nil, fmt.Errorf("invalid range: from block %d is after to block %d", fromBlock, toBlock)
+	}
+	if head := a.CurrentHeader().Number.Uint64(); toBlock > head {
+		return nil, fmt.Errorf("block %d not found: beyond head %d", toBlock, head)
+	}
+	if limit := a.b.config.ReceiptsMaxBlockCount; limit > 0 && toBlock-fromBlock >= limit {
+		return nil, arbitrum_types.NewLimitExceededError(fmt.Sprintf("requested %d blocks, the limit is %d", toBlock-fromBlock+1, limit))
+	}
+	history := make([]uint64, 0, toBlock-fromBlock+1)
+	for number := fromBlock; number <= toBlock; number++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		_, receipts, err := a.blockAndReceipts(ctx, rpc.BlockNumber(number))
+		if err != nil {
+			return nil, err
+		}
+		var l1GasUsed uint64
+		for _, receipt := range receipts {
+			l1GasUsed += receipt.GasUsedForL1
+		}
+		history = append(history, l1GasUsed)
+	}
+	return history, nil
+}
+
+// blockTips holds the effective tips paid by a block's transactions, sorted by tip like geth does,
+// from which the rewards at any percentiles of the block's gas are read
+type blockTips struct {
+	gasUsed uint64
+	sorted  []gasAndReward
+}
+
+type gasAndReward struct {
+	gasUsed uint64
+	reward  *big.Int
+}
+
+// blockTips collects the tips paid in a block, as the effective gas price paid above the base fee.
+// Nitro never charges the tip, so its blocks report zero tips whatever the transactions offered.
+// Tips below zero, paid by transactions with a gas price under the base fee, count as zero.
+func (a *APIBackend) blockTips(ctx context.Context, number rpc.BlockNumber) (*blockTips, error) {
+	block, receipts, err := a.blockAndReceipts(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		txs    = block.Transactions()
+		header = block.Header()
+		tips   = &blockTips{gasUsed: block.GasUsed(), sorted: make([]gasAndReward, len(txs))}
+	)
+	for i, tx := range txs {
+		reward := effectiveGasPrice(a.ChainConfig(), header, tx)
+		if baseFee := block.BaseFee(); baseFee != nil {
+			reward = new(big.Int).Sub(reward, baseFee)
+		}
+		if reward.Sign() < 0 {
+			reward = common.Big0
+		}
+		tips.sorted[i] = gasAndReward{receipts[i].GasUsed, reward}
+	}
+	sort.SliceStable(tips.sorted, func(i, j int) bool {
+		return tips.sorted[i].reward.Cmp(tips.sorted[j].reward) < 0
+	})
+	return tips, nil
+}
+
+// rewards returns the effective tips paid at the given percentiles of the block's gas
+func (t *blockTips) rewards(percentiles []float64) []*big.Int {
+	rewards := make([]*big.Int, len(percentiles))
+	if len(t.sorted) == 0 {
+		for i := range rewards {
+			rewards[i] = common.Big0
+		}
+		return rewards
+	}
+	var txIndex int
+	sumGasUsed := t.sorted[0].gasUsed
+	for i, p := range percentiles {
+		thresholdGasUsed := uint64(float64(t.gasUsed) * p / 100)
+		for sumGasUsed < thresholdGasUsed && txIndex < len(t.sorted)-1 {
+			txIndex++
+			sumGasUsed += t.sorted[txIndex].gasUsed
+		}
+		rewards[i] = t.sorted[txIndex].reward
+	}
+	return rewards
+}
+
+// effectiveGasPrice returns the gas price a transaction paid, as its receipt reports it.
+// Nitro charges every transaction the block's base fee and never the tip, classic blocks record the price paid in the transaction.
+func effectiveGasPrice(config *params.ChainConfig, header *types.Header, tx *types.Transaction) *big.Int {
+	if config.IsArbitrum() {
+		if config.IsArbitrumNitro(header.Number) {
+			return header.BaseFee
+		}
+		if arbTx, ok := tx.GetInner().(*types.ArbitrumLegacyTxData); ok {
+			return new(big.Int).SetUint64(arbTx.EffectiveGasPrice)
+		}
+	}
+	if header.BaseFee == nil {
+		return tx.GasPrice()
+	}
+	return new(big.Int).Add(header.BaseFee, tx.EffectiveGasTipValue(header.BaseFee))
+}
+
+// medianEffectiveGasPrice returns the gas weighted median of the gas prices paid by the block's transactions
+func medianEffectiveGasPrice(config *params.ChainConfig, block *types.Block, receipts types.Receipts) *big.Int {
+	type paid struct {
+		price   *big.Int
+		gasUsed uint64
+	}
+	var (
+		payments []paid
+		totalGas uint64
+	)
+	header := block.Header()
+	for i, tx := range block.Transactions() {
+		payments = append(payments, paid{effectiveGasPrice(config, header, tx), receipts[i].GasUsed})
+		totalGas += receipts[i].GasUsed
+	}
+	if totalGas == 0 {
+		return nil
+	}
+	sort.SliceStable(payments, func(i, j int) bool {
+		return payments[i].price.Cmp(payments[j].price) < 0
+	})
+	var cumulativeGas uint64
+	for _, payment := range payments {
+		cumulativeGas += payment.gasUsed
+		if 2*cumulativeGas >= totalGas {
+			return payment.price
+		}
+	}
+	return payments[len(payments)-1].price
+}
@@ -0,0 +1,326 @@
+package arbitrum
+
+import (
+	"context"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/youngqqcn/arbitrum/common"
+	"github.com/youngqqcn/arbitrum/common/hexutil"
+	"github.com/youngqqcn/arbitrum/core"
+	"github.com/youngqqcn/arbitrum/core/rawdb"
+	"github.com/youngqqcn/arbitrum/core/types"
+	"github.com/youngqqcn/arbitrum/internal/ethapi"
+	"github.com/youngqqcn/arbitrum/params"
+	"github.com/youngqqcn/arbitrum/rpc"
+)
+
+func TestExtendedFeeHistory(t *testing.T) {
+	setTestSpeedLimit(t, 7_000_000)
+
+	// The transactions of each block offer its base fee plus these tips, but Nitro only charges the base fee
+	tips := [][]int64{
+		{1, 5, 3},
+		{},
+		{7, 2},
+		{4},
+	}
+	signer := types.LatestSigner(params.ArbitrumDevTestChainConfig())
+	nonce := uint64(0)
+	wantMedians := make([]*big.Int, len(tips))
+	api, _ := newTestAPIBackend(t, len(tips), func(i int, b *core.BlockGen) {
+		for _, tip := range tips[i] {
+			price := new(big.Int).Add(b.BaseFee(), big.NewInt(tip))
+			tx, err := types.SignTx(types.NewTransaction(nonce, common.Address{0xaa}, big.NewInt(1000), params.TxGas, price, nil), signer, testKey)
+			if err != nil {
+				t.Fatalf("failed to sign tx: %v", err)
+			}
+			b.AddTx(tx)
+			nonce++
+		}
+		if len(tips[i]) > 0 {
+			wantMedians[i] = b.BaseFee()
+		}
+	})
+
+	result, err := api.ExtendedFeeHistory(context.Background(), len(tips), rpc.LatestBlockNumber, nil)
+	if err != nil {
+		t.Fatalf("extended fee history failed: %v", err)
+	}
+	if result.OldestBlock.Uint64() != 1 {
+		t.Fatalf("wrong oldest block: have %v, want 1", result.OldestBlock)
+	}
+	if len(result.MedianEffectiveGasPrice) != len(tips) {
+		t.Fatalf("wrong number of median prices: have %d, want %d", len(result.MedianEffectiveGasPrice), len(tips))
+	}
+	for i, median := range result.MedianEffectiveGasPrice {
+		want := wantMedians[i]
+		if (median == nil) != (want == nil) || (median != nil && median.Cmp(want) != 0) {
+			t.Errorf("block %d: wrong median effective gas price: have %v, want %v", i+1, median, want)
+		}
+	}
+}
+
+func TestEffectiveGasPrice(t *testing.T) {
+	baseFee := big.NewInt(100)
+	tx := types.NewTransaction(0, common.Address{0xaa}, big.NewInt(1000), params.TxGas, big.NewInt(150), nil)
+	classicTx, err := types.NewArbitrumLegacyTx(tx, common.Hash{0x01}, 120, 1, nil)
+	if err != nil {
+		t.Fatalf("failed to wrap classic tx: %v", err)
+	}
+	nitro := params.ArbitrumDevTestChainConfig()
+	classic := params.ArbitrumDevTestChainConfig()
+	classic.ArbitrumChainParams.GenesisBlockNum = 10
+
+	tests := []struct {
+		name   string
+		config *params.ChainConfig
+		number int64
+		tx     *types.Transaction
+		want   int64
+	}{
+		{"nitro", nitro, 1, tx, 100},
+		{"classic", classic, 1, classicTx, 120},
+		{"ethereum", params.TestChainConfig, 1, tx, 150},
+	}
+	for _, tt := range tests {
+		header := &types.Header{Number: big.NewInt(tt.number), BaseFee: baseFee}
+		if price := effectiveGasPrice(tt.config, header, tt.tx); price.Int64() != tt.want {
+			t.Errorf("%s: wrong effective gas price: have %v, want %d", tt.name, price, tt.want)
+		}
+	}
+}
+
+func TestFeeHistoryBlobColumns(t *testing.T) {
+	setTestSpeedLimit(t, 7_000_000)
+	api, _ := newTestAPIBackend(t, 3, transferGenerator(t, 1))
+
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("eth", ethapi.NewEthereumAPI(api)); err != nil {
+		t.Fatal(err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	tests := []struct {
+		blocks        int
+		newest        rpc.BlockNumber
+		omitProjected bool
+		wantBlocks    int
+	}{
+		{2, 2, false, 2},
+		{10, 3, false, 4}, // clipped to the genesis
+		{10, 3, true, 4},
+	}
+	for _, tt := range tests {
+		api.b.config.FeeHistoryOmitProjected = tt.omitProjected
+		var result struct {
+			BaseFee          []*hexutil.Big `json:"baseFeePerGas"`
+			GasUsedRatio     []float64      `json:"gasUsedRatio"`
+			BlobBaseFee      []*hexutil.Big `json:"baseFeePerBlobGas"`
+			BlobGasUsedRatio []float64      `json:"blobGasUsedRatio"`
+		}
+		if err := client.Call(&result, "eth_feeHistory", tt.blocks, tt.newest, nil); err != nil {
+			t.Fatalf("failed to get fee history: %v", err)
+		}
+		if len(result.GasUsedRatio) != tt.wantBlocks {
+			t.Fatalf("wrong number of blocks: have %d, want %d", len(result.GasUsedRatio), tt.wantBlocks)
+		}
+		if len(result.BlobBaseFee) != len(result.BaseFee) {
+			t.Errorf("blob base fees not aligned with base fees: have %d, want %d", len(result.BlobBaseFee), len(result.BaseFee))
+		}
+		if len(result.BlobGasUsedRatio) != len(result.GasUsedRatio) {
+			t.Errorf("blob gas used ratios not aligned with gas used ratios: have %d, want %d", len(result.BlobGasUsedRatio), len(result.GasUsedRatio))
+		}
+		for i, fee := range result.BlobBaseFee {
+			if fee.ToInt().Sign() != 0 {
+				t.Errorf("block %d: non-zero blob base fee %v", i, fee)
+			}
+		}
+	}
+}
+
+func TestFeeHistoryCache(t *testing.T) {
+	setTestSpeedLimit(t, 7_000_000)
+	api, _ := newTestAPIBackend(t, 10, transferGenerator(t, 1))
+
+	oldest, _, basefees, gasUsed, err := api.FeeHistory(context.Background(), 4, 8, nil)
+	if err != nil {
+		t.Fatalf("fee history failed: %v", err)
+	}
+	if api.feeHistoryCache.Len() != 1 {
+		t.Fatalf("window wasn't cached")
+	}
+	cachedOldest, _, cachedBasefees, cachedGasUsed, err := api.FeeHistory(context.Background(), 4, 8, nil)
+	if err != nil {
+		t.Fatalf("fee history failed: %v", err)
+	}
+	if cachedOldest.Cmp(oldest) != 0 || !reflect.DeepEqual(cachedBasefees, basefees) || !reflect.DeepEqual(cachedGasUsed, gasUsed) {
+		t.Errorf("cached fee history differs: have %v %v %v, want %v %v %v", cachedOldest, cachedBasefees, cachedGasUsed, oldest, basefees, gasUsed)
+	}
+
+	// Rewards are served from the tips cached with the window, whatever the percentiles
+	_, rewards, _, _, err := api.FeeHistory(context.Background(), 4, 8, []float64{50})
+	if err != nil {
+		t.Fatalf("fee history failed: %v", err)
+	}
+	entry, _ := api.feeHistoryCache.Get(feeHistoryCacheKey{oldestBlock: 5, newestHash: api.blockChain().GetHeaderByNumber(8).Hash(), speedLimit: 7_000_000})
+	if entry == nil || len(entry.tips) != 4 {
+		t.Fatalf("tips weren't cached with the window")
+	}
+	if len(rewards) != 4 {
+		t.Fatalf("wrong number of reward entries: have %d, want 4", len(rewards))
+	}
+	marker := big.NewInt(12345)
+	entry.tips[0] = &blockTips{gasUsed: params.TxGas, sorted: []gasAndReward{{params.TxGas, marker}}}
+	_, rewards, _, _, err = api.FeeHistory(context.Background(), 4, 8, []float64{10, 90})
+	if err != nil {
+		t.Fatalf("fee history failed: %v", err)
+	}
+	if rewards[0][0] != marker || rewards[0][1] != marker {
+		t.Errorf("rewards not read from the cached tips: %v", rewards[0])
+	}
+
+	// Windows ending at the latest block have a changing prediction and aren't cached
+	if _, _, _, _, err := api.FeeHistory(context.Background(), 4, rpc.LatestBlockNumber, nil); err != nil {
+		t.Fatalf("fee history failed: %v", err)
+	}
+	if api.feeHistoryCache.Len() != 1 {
+		t.Errorf("window including the latest block was cached")
+	}
+}
+
+func BenchmarkFeeHistoryCache(b *testing.B) {
+	setTestSpeedLimit(b, 7_000_000)
+	api, _ := newTestAPIBackend(b, 200, transferGenerator(b, 2))
+	cache := api.feeHistoryCache
+
+	for _, bench := range []struct {
+		name  string
+		cache bool
+	}{
+		{"uncached", false},
+		{"cached", true},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			api.feeHistoryCache = nil
+			if bench.cache {
+				api.feeHistoryCache = cache
+			}
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				// A hot window, ending just before the head
+				if _, _, _, _, err := api.FeeHistory(context.Background(), 128, 190, nil); err != nil {
+					b.Fatalf("fee history failed: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestFeeHistoryRewardPercentiles(t *testing.T) {
+	// The transactions of each block paid these tips, sorted, with equal gas usage
+	tips := [][]int64{
+		{1, 3, 5},
+		{},
+		{2, 7},
+		{4},
+	}
+	want := [][]int64{
+		{1, 3, 5},
+		{0, 0, 0},
+		{2, 2, 7},
+		{4, 4, 4},
+	}
+	for i := range tips {
+		block := &blockTips{gasUsed: uint64(len(tips[i])) * params.TxGas}
+		for _, tip := range tips[i] {
+			block.sorted = append(block.sorted, gasAndReward{params.TxGas, big.NewInt(tip)})
+		}
+		for j, reward := range block.rewards([]float64{0, 50, 100}) {
+			if reward.Int64() != want[i][j] {
+				t.Errorf("block %d percentile %d: wrong reward: have %v, want %d", i+1, j, reward, want[i][j])
+			}
+		}
+	}
+}
+
+func TestFeeHistoryNitroRewards(t *testing.T) {
+	setTestSpeedLimit(t, 7_000_000)
+
+	// Nitro charges the base fee only, so offering a tip doesn't make the transaction pay one
+	signer := types.LatestSigner(params.ArbitrumDevTestChainConfig())
+	api, _ := newTestAPIBackend(t, 1, func(i int, b *core.BlockGen) {
+		tip := big.NewInt(5)
+		tx, err := types.SignNewTx(testKey, signer, &types.DynamicFeeTx{
+			ChainID:   signer.ChainID(),
+			Nonce:     0,
+			GasTipCap: tip,
+			GasFeeCap: new(big.Int).Add(b.BaseFee(), tip),
+			Gas:       params.TxGas,
+			To:        &common.Address{0xaa},
+			Value:     big.NewInt(1000),
+		})
+		if err != nil {
+			t.Fatalf("failed to sign tx: %v", err)
+		}
+		b.AddTx(tx)
+	})
+
+	_, rewards, _, _, err := api.FeeHistory(context.Background(), 1, rpc.LatestBlockNumber, []float64{0, 50, 100})
+	if err != nil {
+		t.Fatalf("fee history failed: %v", err)
+	}
+	if len(rewards) != 1 {
+		t.Fatalf("wrong number of reward entries: have %d, want 1", len(rewards))
+	}
+	for j, reward := range rewards[0] {
+		if reward.Sign() != 0 {
+			t.Errorf("percentile %d: reported a tip nobody paid: %v", j, reward)
+		}
+	}
+
+	if _, _, _, _, err := api.FeeHistory(context.Background(), 1, rpc.LatestBlockNumber, []float64{50, 10}); err == nil {
+		t.Error("expected error for unsorted percentiles")
+	}
+}
+
+func TestGetL1GasUsedHistory(t *testing.T) {
+	api, blocks := newTestAPIBackend(t, 4, transferGenerator(t, 2))
+
+	// Record known L1 gas in the stored receipts
+	want := make([]uint64, len(blocks))
+	for i, block := range blocks {
+		receipts := rawdb.ReadRawReceipts(api.ChainDb(), block.Hash(), block.NumberU64())
+		for j, receipt := range receipts {
+			receipt.GasUsedForL1 = uint64(100*(i+1) + j)
+			want[i] += receipt.GasUsedForL1
+		}
+		rawdb.WriteReceipts(api.ChainDb(), block.Hash(), block.NumberU64(), receipts)
+	}
+
+	first, last := blocks[0].NumberU64(), blocks[len(blocks)-1].NumberU64()
+	history, err := api.GetL1GasUsedHistory(context.Background(), first, last)
+	if err != nil {
+		t.Fatalf("failed to get L1 gas history: %v", err)
+	}
+	if !reflect.DeepEqual(history, want) {
+		t.Errorf("wrong L1 gas history: have %v, want %v", history, want)
+	}
+
+	if _, err := api.GetL1GasUsedHistory(context.Background(), last, first); err == nil {
+		t.Error("expected error for inverted range")
+	}
+	if _, err := api.GetL1GasUsedHistory(context.Background(), first, last+1); err == nil {
+		t.Error("expected error for range beyond the head")
+	}
+	api.b.config.ReceiptsMaxBlockCount = 2
+	if _, err := api.GetL1GasUsedHistory(context.Background(), first, last); err == nil {
+		t.Error("expected error for range over the limit")
+	}
+	if _, err := api.GetL1GasUsedHistory(context.Background(), first, first+1); err != nil {
+		t.Errorf("range within the limit rejected: %v", err)
+	}
+}
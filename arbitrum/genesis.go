@@ -0,0 +1,52 @@
+package arbitrum
+
+import (
+	"context"
+	"errors"
+
+	"github.com/youngqqcn/arbitrum/common"
+	"github.com/youngqqcn/arbitrum/core/types"
+	"github.com/youngqqcn/arbitrum/rpc"
+)
+
+func (a *APIBackend) nitroGenesisHeader(ctx context.Context) (*types.Header, error) {
+	header, err := a.HeaderByNumber(ctx, rpc.BlockNumber(a.ChainConfig().ArbitrumChainParams.GenesisBlockNum))
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, errors.New("genesis header not found")
+	}
+	return header, nil
+}
+
+// GenesisStateRoot returns the state root of the Nitro genesis block
+func (a *APIBackend) GenesisStateRoot(ctx context.Context) (common.Hash, error) {
+	header, err := a.nitroGenesisHeader(ctx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return header.Root, nil
+}
+
+// GenesisMetadata describes where the Nitro chain starts
+type GenesisMetadata struct {
+	BlockNumber   uint64      `json:"blockNumber"`
+	BlockHash     common.Hash `json:"blockHash"`
+	Timestamp     uint64      `json:"timestamp"`
+	L1BlockNumber uint64      `json:"l1BlockNumber"` // zero if the genesis header doesn't record it
+}
+
+// GenesisMetadata returns the number, hash, timestamp and L1 block of the Nitro genesis block
+func (a *APIBackend) GenesisMetadata(ctx context.Context) (*GenesisMetadata, error) {
+	header, err := a.nitroGenesisHeader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &GenesisMetadata{
+		BlockNumber:   a.ChainConfig().ArbitrumChainParams.GenesisBlockNum,
+		BlockHash:     header.Hash(),
+		Timestamp:     header.Time,
+		L1BlockNumber: types.DeserializeHeaderExtraInformation(header).L1BlockNumber,
+	}, nil
+}
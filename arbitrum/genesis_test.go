@@ -0,0 +1,52 @@
+package arbitrum
+
+import (
+	"context"
+	"testing"
+
+	"github.com/youngqqcn/arbitrum/core/types"
+	"github.com/youngqqcn/arbitrum/rpc"
+)
+
+func TestGenesisStateRoot(t *testing.T) {
+	api, _ := newTestAPIBackend(t, 2, nil)
+
+	root, err := api.GenesisStateRoot(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get genesis state root: %v", err)
+	}
+	genesisNum := rpc.BlockNumber(api.ChainConfig().ArbitrumChainParams.GenesisBlockNum)
+	_, header, err := api.StateAndHeaderByNumber(context.Background(), genesisNum)
+	if err != nil {
+		t.Fatalf("failed to get genesis state: %v", err)
+	}
+	if root != header.Root {
+		t.Errorf("wrong genesis state root: have %v, want %v", root, header.Root)
+	}
+}
+
+func TestGenesisMetadata(t *testing.T) {
+	api, _ := newTestAPIBackend(t, 2, nil)
+
+	metadata, err := api.GenesisMetadata(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get genesis metadata: %v", err)
+	}
+	genesisNum := api.ChainConfig().ArbitrumChainParams.GenesisBlockNum
+	header, err := api.HeaderByNumber(context.Background(), rpc.BlockNumber(genesisNum))
+	if err != nil {
+		t.Fatalf("failed to get genesis header: %v", err)
+	}
+	want := GenesisMetadata{
+		BlockNumber:   genesisNum,
+		BlockHash:     header.Hash(),
+		Timestamp:     header.Time,
+		L1BlockNumber: types.DeserializeHeaderExtraInformation(header).L1BlockNumber,
+	}
+	if *metadata != want {
+		t.Errorf("wrong genesis metadata: have %+v, want %+v", *metadata, want)
+	}
+	if header.Number.Uint64() != genesisNum {
+		t.Errorf("genesis header has wrong number: have %d, want %d", header.Number.Uint64(), genesisNum)
+	}
+}
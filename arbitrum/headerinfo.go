@@ -0,0 +1,36 @@
+package arbitrum
+
+import (
+	"errors"
+
+	"github.com/youngqqcn/arbitrum/common"
+	"github.com/youngqqcn/arbitrum/core/types"
+)
+
+var errNoArbHeaderInfo = errors.New("header carries no Arbitrum extension")
+
+// ArbHeaderExtra is the Arbitrum-specific information ArbOS encodes into a block header
+type ArbHeaderExtra struct {
+	SendRoot           common.Hash `json:"sendRoot"`
+	SendCount          uint64      `json:"sendCount"`
+	L1BlockNumber      uint64      `json:"l1BlockNumber"`
+	ArbOSFormatVersion uint64      `json:"arbOSFormatVersion"`
+}
+
+// ArbHeaderInfo parses the Arbitrum extension fields out of a header.
+// Headers without an ArbOS encoded extension (imported classic blocks, genesis) return an error.
+func ArbHeaderInfo(header *types.Header) (*ArbHeaderExtra, error) {
+	if header == nil {
+		return nil, errors.New("header not found")
+	}
+	info := types.DeserializeHeaderExtraInformation(header)
+	if info == (types.HeaderInfo{}) {
+		return nil, errNoArbHeaderInfo
+	}
+	return &ArbHeaderExtra{
+		SendRoot:           info.SendRoot,
+		SendCount:          info.SendCount,
+		L1BlockNumber:      info.L1BlockNumber,
+		ArbOSFormatVersion: info.ArbOSFormatVersion,
+	}, nil
+}
@@ -0,0 +1,47 @@
+package arbitrum
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/youngqqcn/arbitrum/common"
+	"github.com/youngqqcn/arbitrum/core/types"
+)
+
+func TestArbHeaderInfo(t *testing.T) {
+	header := &types.Header{
+		Number:     big.NewInt(100),
+		Difficulty: common.Big1,
+		BaseFee:    big.NewInt(100000000),
+	}
+	expected := types.HeaderInfo{
+		SendRoot:           common.HexToHash("0x1234"),
+		SendCount:          42,
+		L1BlockNumber:      15000000,
+		ArbOSFormatVersion: 10,
+	}
+	expected.UpdateHeaderWithInfo(header)
+
+	extra, err := ArbHeaderInfo(header)
+	if err != nil {
+		t.Fatalf("failed to parse header: %v", err)
+	}
+	if extra.SendRoot != expected.SendRoot {
+		t.Errorf("send root mismatch: have %v, want %v", extra.SendRoot, expected.SendRoot)
+	}
+	if extra.SendCount != expected.SendCount {
+		t.Errorf("send count mismatch: have %d, want %d", extra.SendCount, expected.SendCount)
+	}
+	if extra.L1BlockNumber != expected.L1BlockNumber {
+		t.Errorf("l1 block number mismatch: have %d, want %d", extra.L1BlockNumber, expected.L1BlockNumber)
+	}
+	if extra.ArbOSFormatVersion != expected.ArbOSFormatVersion {
+		t.Errorf("arbos version mismatch: have %d, want %d", extra.ArbOSFormatVersion, expected.ArbOSFormatVersion)
+	}
+
+	// A header without a base fee was imported and has no extension
+	header.BaseFee = nil
+	if _, err := ArbHeaderInfo(header); err == nil {
+		t.Error("expected error for header without Arbitrum extension")
+	}
+}
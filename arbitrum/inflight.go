@@ -0,0 +1,229 @@
+package arbitrum
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/youngqqcn/arbitrum/common"
+	"github.com/youngqqcn/arbitrum/core"
+	"github.com/youngqqcn/arbitrum/core/types"
+	"github.com/youngqqcn/arbitrum/log"
+	"github.com/youngqqcn/arbitrum/rpc"
+)
+
+const (
+	// inFlightTxTimeout is how long an accepted transaction is tracked without being included before it's considered dropped
+	inFlightTxTimeout = 10 * time.Minute
+
+	// maxInFlightTxs is the number of transactions tracked at most, the oldest one is dropped to make room for a new one
+	maxInFlightTxs = 16384
+)
+
+type inFlightTx struct {
+	tx     *types.Transaction
+	from   common.Address
+	queued bool // still awaiting publish confirmation
+	added  time.Time
+}
+
+// inFlightTxs tracks the transactions passed to EnqueueL2Message that haven't been included in a block yet
+type inFlightTxs struct {
+	mu    sync.Mutex
+	txs   map[common.Hash]*inFlightTx
+	limit int
+}
+
+func newInFlightTxs(limit int) *inFlightTxs {
+	return &inFlightTxs{txs: make(map[common.Hash]*inFlightTx), limit: limit}
+}
+
+func (t *inFlightTxs) add(tx *types.Transaction, from common.Address) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	hash := tx.Hash()
+	if _, ok := t.txs[hash]; !ok && t.limit > 0 && len(t.txs) >= t.limit {
+		t.evictOldest()
+	}
+	t.txs[hash] = &inFlightTx{tx: tx, from: from, queued: true, added: time.Now()}
+}
+
+// evictOldest drops the transaction tracked the longest, the caller must hold the lock
+func (t *inFlightTxs) evictOldest() {
+	var (
+		oldest common.Hash
+		added  time.Time
+	)
+	for hash, entry := range t.txs {
+		if added.IsZero() || entry.added.Before(added) {
+			oldest, added = hash, entry.added
+		}
+	}
+	delete(t.txs, oldest)
+}
+
+func (t *inFlightTxs) published(hash common.Hash) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if entry, ok := t.txs[hash]; ok {
+		entry.queued = false
+	}
+}
+
+func (t *inFlightTxs) remove(hash common.Hash) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.txs, hash)
+}
+
+// prune drops the transactions whose nonce was already used on chain (included or replaced) or that timed out
+func (t *inFlightTxs) prune(nonceAt func(common.Address) uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.pruneLocked(nonceAt)
+}
+
+func (t *inFlightTxs) pruneLocked(nonceAt func(common.Address) uint64) {
+	nonces := make(map[common.Address]uint64)
+	for hash, entry := range t.txs {
+		nonce, ok := nonces[entry.from]
+		if !ok {
+			nonce = nonceAt(entry.from)
+			nonces[entry.from] = nonce
+		}
+		if entry.tx.Nonce() < nonce || time.Since(entry.added) > inFlightTxTimeout {
+			delete(t.txs, hash)
+		}
+	}
+}
+
+// snapshot returns the tracked transactions ordered by sender and nonce, after pruning the stale ones
+func (t *inFlightTxs) snapshot(nonceAt func(common.Address) uint64) []inFlightTx {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.pruneLocked(nonceAt)
+	result := make([]inFlightTx, 0, len(t.txs))
+	for _, entry := range t.txs {
+		result = append(result, *entry)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].from != result[j].from {
+			return bytes.Compare(result[i].from[:], result[j].from[:]) < 0
+		}
+		return result[i].tx.Nonce() < result[j].tx.Nonce()
+	})
+	return result
+}
+
+// headNonces returns a lookup of the nonces at the current head, or zero if its state isn't available
+func (b *Backend) headNonces() func(common.Address) uint64 {
+	statedb, err := b.arb.BlockChain().State()
+	return func(addr common.Address) uint64 {
+		if err != nil {
+			return 0
+		}
+		return statedb.GetNonce(addr)
+	}
+}
+
+func (b *Backend) inFlightSnapshot() []inFlightTx {
+	return b.inFlight.snapshot(b.headNonces())
+}
+
+// pruneInFlight drops the included and timed out transactions from the in-flight set on every new head,
+// so it doesn't grow while nobody asks for a snapshot
+func (b *Backend) pruneInFlight() {
+	ch := make(chan core.ChainHeadEvent, chainHeadChanSize)
+	sub := b.arb.BlockChain().SubscribeChainHeadEvent(ch)
+	defer sub.Unsubscribe()
+	for {
+		select {
+		case <-ch:
+			b.inFlight.prune(b.headNonces())
+		case err := <-sub.Err():
+			if err != nil {
+				log.Error("Stopped pruning in-flight transactions", "err", err)
+			}
+			return
+		case <-b.chanClose:
+			return
+		}
+	}
+}
+
+// TxStats returns the number of accepted transactions the sequencer confirmed but that aren't included yet,
+// and the number still awaiting publish confirmation, counted like TxPoolContent
+func (b *Backend) TxStats() (pending int, queued int) {
+	for _, entry := range b.inFlightSnapshot() {
+		if entry.queued {
+			queued++
+		} else {
+			pending++
+		}
+	}
+	return pending, queued
+}
+
+// TxPoolContent returns the accepted transactions that aren't included in a block yet, grouped by sender and ordered by nonce.
+// Transactions the sequencer confirmed are pending, the ones still awaiting publish confirmation are queued.
+func (b *Backend) TxPoolContent() (map[common.Address]types.Transactions, map[common.Address]types.Transactions) {
+	pending := make(map[common.Address]types.Transactions)
+	queued := make(map[common.Address]types.Transactions)
+	for _, entry := range b.inFlightSnapshot() {
+		if entry.queued {
+			queued[entry.from] = append(queued[entry.from], entry.tx)
+		} else {
+			pending[entry.from] = append(pending[entry.from], entry.tx)
+		}
+	}
+	return pending, queued
+}
+
+// TxPoolContentFrom returns the pending and queued transactions of a single sender, see TxPoolContent
+func (b *Backend) TxPoolContentFrom(addr common.Address) (types.Transactions, types.Transactions) {
+	pending, queued := types.Transactions{}, types.Transactions{}
+	for _, entry := range b.inFlightSnapshot() {
+		if entry.from != addr {
+			continue
+		}
+		if entry.queued {
+			queued = append(queued, entry.tx)
+		} else {
+			pending = append(pending, entry.tx)
+		}
+	}
+	return pending, queued
+}
+
+// GetPendingTransactionsFrom returns the transactions of a sender that were accepted but aren't included in a block yet, ordered by nonce
+func (b *Backend) GetPendingTransactionsFrom(addr common.Address) types.Transactions {
+	txs := types.Transactions{}
+	for _, entry := range b.inFlightSnapshot() {
+		if entry.from == addr {
+			txs = append(txs, entry.tx)
+		}
+	}
+	return txs
+}
+
+// GetNonceGaps returns the nonces missing between a sender's committed nonce and its highest in-flight transaction,
+// which the sequencer holds back until they're filled
+func (a *APIBackend) GetNonceGaps(ctx context.Context, addr common.Address) ([]uint64, error) {
+	statedb, _, err := a.StateAndHeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	next := statedb.GetNonce(addr)
+	gaps := []uint64{}
+	for _, tx := range a.b.GetPendingTransactionsFrom(addr) {
+		for ; next < tx.Nonce(); next++ {
+			gaps = append(gaps, next)
+		}
+		if tx.Nonce() >= next {
+			next = tx.Nonce() + 1
+		}
+	}
+	return gaps, nil
+}
@@ -0,0 +1,163 @@
+package arbitrum
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/youngqqcn/arbitrum/common"
+	"github.com/youngqqcn/arbitrum/core/types"
+)
+
+func TestGetPendingTransactionsFrom(t *testing.T) {
+	api, _ := newTestAPIBackend(t, 1, nil)
+
+	if txs := api.b.GetPendingTransactionsFrom(testAddr); len(txs) != 0 {
+		t.Fatalf("unexpected pending txs: %d", len(txs))
+	}
+	txs := []*types.Transaction{signTestTransfer(t, 1), signTestTransfer(t, 0)}
+	for _, tx := range txs {
+		if err := api.SendTx(context.Background(), tx); err != nil {
+			t.Fatalf("failed to send tx: %v", err)
+		}
+	}
+
+	pending := api.b.GetPendingTransactionsFrom(testAddr)
+	if len(pending) != 2 {
+		t.Fatalf("wrong number of pending txs: have %d, want 2", len(pending))
+	}
+	for i, tx := range pending {
+		if tx.Nonce() != uint64(i) {
+			t.Errorf("pending tx %d: wrong nonce %d", i, tx.Nonce())
+		}
+	}
+	if other := api.b.GetPendingTransactionsFrom(common.Address{0xbb}); len(other) != 0 {
+		t.Errorf("unexpected pending txs for other sender: %d", len(other))
+	}
+}
+
+func TestGetNonceGaps(t *testing.T) {
+	api, _ := newTestAPIBackend(t, 1, transferGenerator(t, 1))
+
+	for _, nonce := range []uint64{1, 2, 4, 7} {
+		if err := api.SendTx(context.Background(), signTestTransfer(t, nonce)); err != nil {
+			t.Fatalf("failed to send tx: %v", err)
+		}
+	}
+	gaps, err := api.GetNonceGaps(context.Background(), testAddr)
+	if err != nil {
+		t.Fatalf("failed to get nonce gaps: %v", err)
+	}
+	if want := []uint64{3, 5, 6}; !reflect.DeepEqual(gaps, want) {
+		t.Errorf("wrong nonce gaps: have %v, want %v", gaps, want)
+	}
+
+	for _, nonce := range []uint64{3, 5, 6} {
+		if err := api.SendTx(context.Background(), signTestTransfer(t, nonce)); err != nil {
+			t.Fatalf("failed to send tx: %v", err)
+		}
+	}
+	gaps, err = api.GetNonceGaps(context.Background(), testAddr)
+	if err != nil {
+		t.Fatalf("failed to get nonce gaps: %v", err)
+	}
+	if len(gaps) != 0 {
+		t.Errorf("unexpected nonce gaps for contiguous nonces: %v", gaps)
+	}
+}
+
+func TestStats(t *testing.T) {
+	api, _ := newTestAPIBackend(t, 1, nil)
+
+	if pending, queued := api.Stats(); pending != 0 || queued != 0 {
+		t.Fatalf("unexpected stats for idle backend: pending %d, queued %d", pending, queued)
+	}
+	// A published transaction is pending, the ones awaiting publish confirmation are queued
+	if err := api.SendTx(context.Background(), signTestTransfer(t, 0)); err != nil {
+		t.Fatalf("failed to send tx: %v", err)
+	}
+	api.b.inFlight.add(signTestTransfer(t, 1), testAddr)
+	api.b.inFlight.add(signTestTransfer(t, 2), testAddr)
+
+	pending, queued := api.Stats()
+	if pending != 1 {
+		t.Errorf("wrong pending count: have %d, want 1", pending)
+	}
+	if queued != 2 {
+		t.Errorf("wrong queued count: have %d, want 2", queued)
+	}
+	content, queuedContent := api.TxPoolContent()
+	if len(content[testAddr]) != pending || len(queuedContent[testAddr]) != queued {
+		t.Errorf("stats %d/%d differ from the content %d/%d", pending, queued, len(content[testAddr]), len(queuedContent[testAddr]))
+	}
+}
+
+func TestTxPoolContent(t *testing.T) {
+	api, _ := newTestAPIBackend(t, 1, nil)
+
+	pending, queued := api.TxPoolContent()
+	if len(pending) != 0 || len(queued) != 0 {
+		t.Fatalf("unexpected content for idle backend: %d pending, %d queued", len(pending), len(queued))
+	}
+	if _, err := json.Marshal([]interface{}{pending, queued}); err != nil {
+		t.Fatalf("failed to marshal empty content: %v", err)
+	}
+
+	for _, nonce := range []uint64{1, 0} {
+		if err := api.SendTx(context.Background(), signTestTransfer(t, nonce)); err != nil {
+			t.Fatalf("failed to send tx: %v", err)
+		}
+	}
+	awaiting := signTestTransfer(t, 2)
+	api.b.inFlight.add(awaiting, testAddr)
+
+	pending, queued = api.TxPoolContent()
+	if len(pending[testAddr]) != 2 || pending[testAddr][0].Nonce() != 0 || pending[testAddr][1].Nonce() != 1 {
+		t.Errorf("wrong pending content: %v", pending[testAddr])
+	}
+	if len(queued[testAddr]) != 1 || queued[testAddr][0].Hash() != awaiting.Hash() {
+		t.Errorf("wrong queued content: %v", queued[testAddr])
+	}
+
+	pendingFrom, queuedFrom := api.TxPoolContentFrom(testAddr)
+	if len(pendingFrom) != 2 || len(queuedFrom) != 1 {
+		t.Errorf("wrong content from sender: %d pending, %d queued", len(pendingFrom), len(queuedFrom))
+	}
+	pendingFrom, queuedFrom = api.TxPoolContentFrom(common.Address{0xbb})
+	if len(pendingFrom) != 0 || len(queuedFrom) != 0 {
+		t.Errorf("unexpected content from other sender: %d pending, %d queued", len(pendingFrom), len(queuedFrom))
+	}
+}
+
+func TestInFlightPruning(t *testing.T) {
+	inFlight := newInFlightTxs(2)
+	nonce := uint64(0)
+	nonceAt := func(common.Address) uint64 { return nonce }
+
+	txs := []*types.Transaction{signTestTransfer(t, 0), signTestTransfer(t, 1), signTestTransfer(t, 2)}
+	inFlight.add(txs[0], testAddr)
+	inFlight.txs[txs[0].Hash()].added = time.Now().Add(-time.Second)
+	inFlight.add(txs[1], testAddr)
+	inFlight.add(txs[2], testAddr)
+
+	// The oldest transaction makes room once the limit is reached
+	if len(inFlight.txs) != 2 {
+		t.Fatalf("wrong number of tracked txs: have %d, want 2", len(inFlight.txs))
+	}
+	if _, ok := inFlight.txs[txs[0].Hash()]; ok {
+		t.Error("oldest tx not evicted")
+	}
+
+	nonce = 2
+	inFlight.prune(nonceAt)
+	if len(inFlight.txs) != 1 {
+		t.Fatalf("included tx not pruned: have %d tracked txs, want 1", len(inFlight.txs))
+	}
+	inFlight.txs[txs[2].Hash()].added = time.Now().Add(-inFlightTxTimeout - time.Second)
+	inFlight.prune(nonceAt)
+	if len(inFlight.txs) != 0 {
+		t.Errorf("timed out tx not pruned: have %d tracked txs", len(inFlight.txs))
+	}
+}
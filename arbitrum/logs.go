@@ -0,0 +1,306 @@
+package arbitrum
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/youngqqcn/arbitrum/common"
+	"github.com/youngqqcn/arbitrum/common/hexutil"
+	"github.com/youngqqcn/arbitrum/core"
+	"github.com/youngqqcn/arbitrum/core/rawdb"
+	"github.com/youngqqcn/arbitrum/core/types"
+	"github.com/youngqqcn/arbitrum/eth/filters"
+	"github.com/youngqqcn/arbitrum/event"
+	"github.com/youngqqcn/arbitrum/rlp"
+	"github.com/youngqqcn/arbitrum/rpc"
+)
+
+var errInvalidPageToken = errors.New("invalid page token")
+
+// SubscribeAllLogsEvent delivers both newly added and reorged-out logs on a single channel, in the order the chain emitted them.
+// Logs removed by a reorg are delivered with Removed set. A consumer falling behind by more than
+// SubscriptionQueueLimit logs is unsubscribed with ErrSubscriptionQueueFull.
+func (a *APIBackend) SubscribeAllLogsEvent(ch chan<- *types.Log) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		// The feeds are read through unbuffered channels so the relative order of
+		// added and removed logs is kept; pending logs are queued locally instead
+		// so a slow consumer never blocks the chain.
+		logsCh := make(chan []*types.Log)
+		rmLogsCh := make(chan core.RemovedLogsEvent)
+		logsSub := a.SubscribeLogsEvent(logsCh)
+		defer logsSub.Unsubscribe()
+		rmLogsSub := a.SubscribeRemovedLogsEvent(rmLogsCh)
+		defer rmLogsSub.Unsubscribe()
+
+		var queue []*types.Log
+		enqueue := func(logs []*types.Log, removed bool) error {
+			for _, log := range logs {
+				if a.queueFull(len(queue)) {
+					return ErrSubscriptionQueueFull
+				}
+				cpy := *log
+				cpy.Removed = removed
+				queue = append(queue, &cpy)
+			}
+			return nil
+		}
+		for {
+			var (
+				out  chan<- *types.Log
+				next *types.Log
+			)
+			if len(queue) > 0 {
+				out, next = ch, queue[0]
+			}
+			select {
+			case logs := <-logsCh:
+				if err := enqueue(logs, false); err != nil {
+					return err
+				}
+			case ev := <-rmLogsCh:
+				if err := enqueue(ev.Logs, true); err != nil {
+					return err
+				}
+			case out <- next:
+				queue = queue[1:]
+			case err := <-logsSub.Err():
+				return err
+			case err := <-rmLogsSub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	})
+}
+
+// logPosition identifies a log within the chain
+type logPosition struct {
+	block    uint64
+	txIndex  uint64
+	logIndex uint64
+}
+
+func (p logPosition) token() string {
+	var buf [24]byte
+	binary.BigEndian.PutUint64(buf[0:], p.block)
+	binary.BigEndian.PutUint64(buf[8:], p.txIndex)
+	binary.BigEndian.PutUint64(buf[16:], p.logIndex)
+	return hexutil.Encode(buf[:])
+}
+
+func parsePageToken(token string) (logPosition, error) {
+	buf, err := hexutil.Decode(token)
+	if err != nil || len(buf) != 24 {
+		return logPosition{}, errInvalidPageToken
+	}
+	return logPosition{
+		block:    binary.BigEndian.Uint64(buf[0:]),
+		txIndex:  binary.BigEndian.Uint64(buf[8:]),
+		logIndex: binary.BigEndian.Uint64(buf[16:]),
+	}, nil
+}
+
+// resolveFilterBlock returns the number of the block a filter bound refers to, with nil meaning the latest block
+func (a *APIBackend) resolveFilterBlock(ctx context.Context, number *big.Int) (uint64, error) {
+	if number != nil && number.Sign() >= 0 {
+		return number.Uint64(), nil
+	}
+	blockNum := rpc.LatestBlockNumber
+	if number != nil {
+		blockNum = rpc.BlockNumber(number.Int64())
+	}
+	header, err := a.HeaderByNumber(ctx, blockNum)
+	if err != nil {
+		return 0, err
+	}
+	if header == nil {
+		return 0, errors.New("header not found")
+	}
+	return header.Number.Uint64(), nil
+}
+
+// GetLogsPaged returns up to LogsPageSize logs (0 = unlimited) matching the filter criteria, starting at the position encoded in pageToken.
+// An empty pageToken starts at the beginning of the range. The returned token continues after the last returned log,
+// and is empty once all matching logs were returned.
+func (a *APIBackend) GetLogsPaged(ctx context.Context, crit filters.FilterCriteria, pageToken string) ([]*types.Log, string, error) {
+	var from, to uint64
+	if crit.BlockHash != nil {
+		header, err := a.HeaderByHash(ctx, *crit.BlockHash)
+		if err != nil {
+			return nil, "", err
+		}
+		if header == nil {
+			return nil, "", errors.New("unknown block")
+		}
+		from, to = header.Number.Uint64(), header.Number.Uint64()
+	} else {
+		var err error
+		if from, err = a.resolveFilterBlock(ctx, crit.FromBlock); err != nil {
+			return nil, "", err
+		}
+		if to, err = a.resolveFilterBlock(ctx, crit.ToBlock); err != nil {
+			return nil, "", err
+		}
+		if limit := a.b.config.MaxFilterRange; limit > 0 && to >= from && to-from >= limit {
+			return nil, "", fmt.Errorf("block range %d-%d exceeds the limit of %d blocks", from, to, limit)
+		}
+	}
+	var start logPosition
+	if pageToken != "" {
+		var err error
+		if start, err = parsePageToken(pageToken); err != nil {
+			return nil, "", err
+		}
+		if start.block < from || start.block > to {
+			return nil, "", errInvalidPageToken
+		}
+	} else {
+		start.block = from
+	}
+
+	pageSize := a.b.config.LogsPageSize
+	var page []*types.Log
+	for number := start.block; number <= to; number++ {
+		if err := ctx.Err(); err != nil {
+			return nil, "", err
+		}
+		header := a.blockChain().GetHeaderByNumber(number)
+		if header == nil {
+			break
+		}
+		if !logsBloomMatches(header.Bloom, crit.Addresses, crit.Topics) {
+			continue
+		}
+		for _, receipt := range a.blockChain().GetReceiptsByHash(header.Hash()) {
+			for _, log := range receipt.Logs {
+				if number == start.block && (uint64(log.TxIndex) < start.txIndex || uint64(log.Index) < start.logIndex) {
+					continue
+				}
+				if !logMatches(log, crit.Addresses, crit.Topics) {
+					continue
+				}
+				if pageSize > 0 && len(page) == pageSize {
+					next := logPosition{number, uint64(log.TxIndex), uint64(log.Index)}
+					return page, next.token(), nil
+				}
+				page = append(page, log)
+			}
+		}
+	}
+	return page, "", nil
+}
+
+func logsBloomMatches(bloom types.Bloom, addresses []common.Address, topics [][]common.Hash) bool {
+	if len(addresses) > 0 {
+		var included bool
+		for _, addr := range addresses {
+			if types.BloomLookup(bloom, addr) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+	for _, sub := range topics {
+		included := len(sub) == 0 // empty rule set == wildcard
+		for _, topic := range sub {
+			if types.BloomLookup(bloom, topic) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+	return true
+}
+
+func logMatches(log *types.Log, addresses []common.Address, topics [][]common.Hash) bool {
+	if len(addresses) > 0 {
+		var included bool
+		for _, addr := range addresses {
+			if log.Address == addr {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+	if len(topics) > len(log.Topics) {
+		return false
+	}
+	for i, sub := range topics {
+		match := len(sub) == 0 // empty rule set == wildcard
+		for _, topic := range sub {
+			if log.Topics[i] == topic {
+				match = true
+				break
+			}
+		}
+		if !match {
+			return false
+		}
+	}
+	return true
+}
+
+// Modes of Config.PendingLogsBehavior
+const (
+	PendingLogsAlias     = "alias"
+	PendingLogsEmpty     = "empty"
+	PendingLogsProjected = "projected"
+)
+
+// PostPendingLogs delivers the logs of the block being produced to pending log subscribers,
+// when the pending logs behavior is "projected"
+func (b *Backend) PostPendingLogs(logs []*types.Log) int {
+	if len(logs) == 0 {
+		return 0
+	}
+	return b.pendingLogsFeed.Send(logs)
+}
+
+// storedReceiptLogs is the storage encoding of a Nitro receipt, with the logs left encoded
+type storedReceiptLogs struct {
+	PostStateOrStatus []byte
+	CumulativeGasUsed uint64
+	L1GasUsed         uint64
+	Logs              []rlp.RawValue
+	Rest              []rlp.RawValue `rlp:"tail"`
+}
+
+// GetLogCount returns the number of logs emitted in a block, without decoding the logs themselves
+func (a *APIBackend) GetLogCount(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (uint64, error) {
+	header, err := a.HeaderByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return 0, err
+	}
+	if header == nil {
+		return 0, errors.New("header not found")
+	}
+	if !a.ChainConfig().IsArbitrumNitro(header.Number) {
+		return 0, types.ErrUseFallback
+	}
+	data := rawdb.ReadReceiptsRLP(a.ChainDb(), header.Hash(), header.Number.Uint64())
+	if len(data) == 0 {
+		return 0, nil
+	}
+	var receipts []storedReceiptLogs
+	if err := rlp.DecodeBytes(data, &receipts); err != nil {
+		return 0, err
+	}
+	var count uint64
+	for _, receipt := range receipts {
+		count += uint64(len(receipt.Logs))
+	}
+	return count, nil
+}
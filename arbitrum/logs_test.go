@@ -0,0 +1,264 @@
+package arbitrum
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/youngqqcn/arbitrum/common"
+	"github.com/youngqqcn/arbitrum/consensus/ethash"
+	"github.com/youngqqcn/arbitrum/core"
+	"github.com/youngqqcn/arbitrum/core/types"
+	"github.com/youngqqcn/arbitrum/eth/filters"
+	"github.com/youngqqcn/arbitrum/rpc"
+)
+
+func TestSubscribeAllLogsEvent(t *testing.T) {
+	api, _ := newTestAPIBackendWithAlloc(t, testEmitterAlloc, 1, nil)
+	parent := api.CurrentBlock()
+
+	ch := make(chan *types.Log)
+	sub := api.SubscribeAllLogsEvent(ch)
+	defer sub.Unsubscribe()
+	time.Sleep(50 * time.Millisecond)
+
+	blocks, _ := core.GenerateChain(api.ChainConfig(), parent, ethash.NewFaker(), api.ChainDb(), 1, emitterGenerator(t, 1, 0))
+	if _, err := api.blockChain().InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	if err := api.blockChain().ReorgToOldBlock(parent); err != nil {
+		t.Fatalf("failed to reorg: %v", err)
+	}
+
+	txHash := blocks[0].Transactions()[0].Hash()
+	for i, wantRemoved := range []bool{false, true} {
+		select {
+		case log := <-ch:
+			if log.TxHash != txHash {
+				t.Errorf("log %d: wrong tx hash: have %v, want %v", i, log.TxHash, txHash)
+			}
+			if log.Removed != wantRemoved {
+				t.Errorf("log %d: wrong removed flag: have %v, want %v", i, log.Removed, wantRemoved)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("log %d not delivered", i)
+		}
+	}
+}
+
+func TestSubscribeAllLogsEventQueueLimit(t *testing.T) {
+	api, _ := newTestAPIBackendWithAlloc(t, testEmitterAlloc, 1, nil)
+	api.b.config.SubscriptionQueueLimit = 2
+
+	// Nobody reads the channel, so every log stays queued
+	sub := api.SubscribeAllLogsEvent(make(chan *types.Log))
+	defer sub.Unsubscribe()
+	time.Sleep(50 * time.Millisecond)
+
+	blocks, _ := core.GenerateChain(api.ChainConfig(), api.CurrentBlock(), ethash.NewFaker(), api.ChainDb(), 1, emitterGenerator(t, 3, 0))
+	if _, err := api.blockChain().InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	select {
+	case err := <-sub.Err():
+		if !errors.Is(err, ErrSubscriptionQueueFull) {
+			t.Errorf("wrong error: have %v, want %v", err, ErrSubscriptionQueueFull)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("slow subscriber not unsubscribed")
+	}
+}
+
+func TestGetLogsPaged(t *testing.T) {
+	api, blocks := newTestAPIBackendWithAlloc(t, testEmitterAlloc, 4, emitterGenerator(t, 3, 0))
+	api.b.config.LogsPageSize = 5
+
+	var want []*types.Log
+	for _, block := range blocks {
+		for _, receipt := range api.blockChain().GetReceiptsByHash(block.Hash()) {
+			want = append(want, receipt.Logs...)
+		}
+	}
+	if len(want) != 12 {
+		t.Fatalf("wrong number of emitted logs: have %d, want 12", len(want))
+	}
+
+	crit := filters.FilterCriteria{
+		FromBlock: big.NewInt(1),
+		ToBlock:   big.NewInt(int64(len(blocks))),
+		Addresses: []common.Address{testEmitter},
+	}
+	var (
+		have  []*types.Log
+		token string
+		pages int
+	)
+	for {
+		page, next, err := api.GetLogsPaged(context.Background(), crit, token)
+		if err != nil {
+			t.Fatalf("page %d: failed to get logs: %v", pages, err)
+		}
+		if len(page) > api.b.config.LogsPageSize {
+			t.Fatalf("page %d: too many logs: have %d, want at most %d", pages, len(page), api.b.config.LogsPageSize)
+		}
+		have = append(have, page...)
+		pages++
+		if next == "" {
+			break
+		}
+		token = next
+	}
+	if pages != 3 {
+		t.Errorf("wrong number of pages: have %d, want 3", pages)
+	}
+	if len(have) != len(want) {
+		t.Fatalf("wrong number of logs: have %d, want %d", len(have), len(want))
+	}
+	for i := range want {
+		if have[i].TxHash != want[i].TxHash || have[i].Index != want[i].Index {
+			t.Errorf("log %d: have tx %v index %d, want tx %v index %d", i, have[i].TxHash, have[i].Index, want[i].TxHash, want[i].Index)
+		}
+	}
+
+	if _, _, err := api.GetLogsPaged(context.Background(), crit, "0x1234"); err == nil {
+		t.Error("expected error for malformed page token")
+	}
+}
+
+func TestGetLogCount(t *testing.T) {
+	api, blocks := newTestAPIBackendWithAlloc(t, testEmitterAlloc, 3, func(i int, b *core.BlockGen) {
+		// blocks with 0, 1 and 2 log emitting transactions
+		emitterGenerator(t, i, uint64(i*(i-1)/2))(i, b)
+	})
+
+	for i, block := range blocks {
+		count, err := api.GetLogCount(context.Background(), rpc.BlockNumberOrHashWithHash(block.Hash(), false))
+		if err != nil {
+			t.Fatalf("block %d: failed to count logs: %v", block.NumberU64(), err)
+		}
+		logs, err := api.GetLogs(context.Background(), block.Hash(), block.NumberU64())
+		if err != nil {
+			t.Fatalf("block %d: failed to get logs: %v", block.NumberU64(), err)
+		}
+		var want int
+		for _, txLogs := range logs {
+			want += len(txLogs)
+		}
+		if want != i {
+			t.Fatalf("block %d: wrong number of emitted logs: have %d, want %d", block.NumberU64(), want, i)
+		}
+		if count != uint64(want) {
+			t.Errorf("block %d: wrong log count: have %d, want %d", block.NumberU64(), count, want)
+		}
+	}
+}
+
+func TestPendingLogsBehavior(t *testing.T) {
+	projected := []*types.Log{{Address: testEmitter, BlockNumber: 2}}
+	tests := []struct {
+		behavior   string
+		wantMined  bool // whether mined logs are delivered
+		wantPosted bool // whether logs posted by the block producer are delivered
+	}{
+		{PendingLogsAlias, true, false},
+		{PendingLogsEmpty, false, false},
+		{PendingLogsProjected, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.behavior, func(t *testing.T) {
+			api, _ := newTestAPIBackendWithAlloc(t, testEmitterAlloc, 1, nil)
+			api.b.config.PendingLogsBehavior = tt.behavior
+			if err := api.b.config.Validate(); err != nil {
+				t.Fatalf("valid behavior rejected: %v", err)
+			}
+
+			ch := make(chan []*types.Log, 2)
+			sub := api.SubscribePendingLogsEvent(ch)
+			defer sub.Unsubscribe()
+
+			blocks, _ := core.GenerateChain(api.ChainConfig(), api.CurrentBlock(), ethash.NewFaker(), api.ChainDb(), 1, emitterGenerator(t, 1, 0))
+			if _, err := api.blockChain().InsertChain(blocks); err != nil {
+				t.Fatalf("failed to insert chain: %v", err)
+			}
+			api.b.PostPendingLogs(projected)
+
+			var gotMined, gotPosted bool
+			timeout := time.After(100 * time.Millisecond)
+		loop:
+			for {
+				select {
+				case logs := <-ch:
+					if logs[0].BlockHash == blocks[0].Hash() {
+						gotMined = true
+					} else if logs[0] == projected[0] {
+						gotPosted = true
+					}
+				case <-timeout:
+					break loop
+				}
+			}
+			if gotMined != tt.wantMined {
+				t.Errorf("mined logs delivered: have %v, want %v", gotMined, tt.wantMined)
+			}
+			if gotPosted != tt.wantPosted {
+				t.Errorf("posted logs delivered: have %v, want %v", gotPosted, tt.wantPosted)
+			}
+		})
+	}
+}
+
+func TestPendingLogsBehaviorValidation(t *testing.T) {
+	config := DefaultConfig
+	config.PendingLogsBehavior = "pending"
+	if err := config.Validate(); err == nil {
+		t.Error("unknown pending logs behavior passed validation")
+	}
+}
+
+func TestGetLogsFutureBlock(t *testing.T) {
+	api, blocks := newTestAPIBackendWithAlloc(t, testEmitterAlloc, 2, emitterGenerator(t, 1, 0))
+	head := blocks[len(blocks)-1]
+
+	logs, err := api.GetLogs(context.Background(), head.Hash(), head.NumberU64())
+	if err != nil {
+		t.Fatalf("failed to get head logs: %v", err)
+	}
+	if len(logs) != 1 {
+		t.Errorf("wrong number of head log sets: have %d, want 1", len(logs))
+	}
+	if _, err := api.GetLogs(context.Background(), common.Hash{0xff}, head.NumberU64()+1); err == nil {
+		t.Error("expected error for block beyond the head")
+	}
+}
+
+func TestMaxFilterRange(t *testing.T) {
+	api, blocks := newTestAPIBackendWithAlloc(t, testEmitterAlloc, 4, emitterGenerator(t, 1, 0))
+	api.b.config.MaxFilterRange = 2
+	sys := filters.NewFilterSystem(api, filters.Config{RangeLimit: api.b.config.MaxFilterRange})
+
+	if _, err := sys.NewRangeFilter(1, int64(len(blocks)), nil, nil).Logs(context.Background()); err == nil {
+		t.Error("expected error for filter over the range limit")
+	}
+	logs, err := sys.NewRangeFilter(2, 3, nil, nil).Logs(context.Background())
+	if err != nil {
+		t.Fatalf("failed to filter within the range limit: %v", err)
+	}
+	if len(logs) != 2 {
+		t.Errorf("wrong number of logs within the range limit: have %d, want 2", len(logs))
+	}
+
+	crit := filters.FilterCriteria{FromBlock: big.NewInt(1), ToBlock: big.NewInt(int64(len(blocks)))}
+	if _, _, err := api.GetLogsPaged(context.Background(), crit, ""); err == nil {
+		t.Error("expected error for paged query over the range limit")
+	}
+	crit = filters.FilterCriteria{FromBlock: big.NewInt(2), ToBlock: big.NewInt(3)}
+	logs, _, err = api.GetLogsPaged(context.Background(), crit, "")
+	if err != nil {
+		t.Fatalf("failed to get paged logs within the range limit: %v", err)
+	}
+	if len(logs) != 2 {
+		t.Errorf("wrong number of paged logs within the range limit: have %d, want 2", len(logs))
+	}
+}
@@ -0,0 +1,142 @@
+package arbitrum
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/youngqqcn/arbitrum/common"
+	"github.com/youngqqcn/arbitrum/core"
+	"github.com/youngqqcn/arbitrum/core/state"
+	"github.com/youngqqcn/arbitrum/core/types"
+	"github.com/youngqqcn/arbitrum/crypto"
+	"github.com/youngqqcn/arbitrum/log"
+	"github.com/youngqqcn/arbitrum/trie"
+)
+
+// pendingBlock is a pending block prediction, valid as long as the head and the in-flight transactions don't change
+type pendingBlock struct {
+	head     common.Hash
+	inFlight common.Hash // fingerprint of the in-flight transactions the prediction executed
+	block    *types.Block
+	receipts types.Receipts
+}
+
+// PendingBlockAndReceipts returns a prediction of the next block, for the "pending" block tag.
+// The prediction executes the transactions accepted by this node but not included yet on top of the head,
+// ordered by sender and nonce, leaving out the ones that can't be executed, e.g. because of a nonce gap.
+// At most PendingBlockMaxTxs transactions are executed, and the prediction is reused until the head or the
+// in-flight transactions change. The sequencer orders transactions by arrival and includes transactions from
+// other nodes, so the actual next block may differ. Without any such transactions, the pending block is the latest one.
+func (a *APIBackend) PendingBlockAndReceipts() (*types.Block, types.Receipts) {
+	head := a.CurrentBlock()
+	if head == nil {
+		return nil, nil
+	}
+	inFlight := a.b.inFlightSnapshot()
+	if limit := a.b.config.PendingBlockMaxTxs; limit > 0 && len(inFlight) > limit {
+		inFlight = inFlight[:limit]
+	}
+	if len(inFlight) > 0 {
+		hashes := make([][]byte, len(inFlight))
+		for i, entry := range inFlight {
+			hashes[i] = entry.tx.Hash().Bytes()
+		}
+		fingerprint := crypto.Keccak256Hash(hashes...)
+
+		a.pendingMutex.Lock()
+		defer a.pendingMutex.Unlock()
+		if cached := a.pending; cached != nil && cached.head == head.Hash() && cached.inFlight == fingerprint {
+			return cached.block, cached.receipts
+		}
+		block, receipts, err := a.predictNextBlock(head, inFlight)
+		if err == nil {
+			a.pending = &pendingBlock{head: head.Hash(), inFlight: fingerprint, block: block, receipts: receipts}
+			return block, receipts
+		}
+		log.Warn("Failed to predict the pending block, using the latest block", "err", err)
+	}
+	return head, a.blockChain().GetReceiptsByHash(head.Hash())
+}
+
+// startBlockTx returns the internal transaction ArbOS starts the block following parent with,
+// or nil if ArbOS isn't installed
+func (a *APIBackend) startBlockTx(parent *types.Block, header *types.Header, statedb *state.StateDB) (*types.Transaction, error) {
+	if core.InternalTxStartBlock == nil || !a.ChainConfig().IsArbitrum() {
+		return nil, nil
+	}
+	l1BaseFee := new(big.Int)
+	if core.GetArbOSL1BaseFeeEstimate != nil {
+		var err error
+		if l1BaseFee, err = core.GetArbOSL1BaseFeeEstimate(statedb); err != nil {
+			return nil, err
+		}
+	}
+	l1BlockNum := types.DeserializeHeaderExtraInformation(parent.Header()).L1BlockNumber
+	return types.NewTx(core.InternalTxStartBlock(a.ChainConfig().ChainID, l1BaseFee, l1BlockNum, header, parent.Header())), nil
+}
+
+// predictNextBlock executes the given transactions in a block following parent, after the ArbOS start block transaction
+func (a *APIBackend) predictNextBlock(parent *types.Block, inFlight []inFlightTx) (*types.Block, types.Receipts, error) {
+	bc := a.blockChain()
+	statedb, err := bc.StateAt(parent.Root())
+	if err != nil {
+		return nil, nil, err
+	}
+	timestamp := uint64(time.Now().Unix())
+	if timestamp < parent.Time() {
+		timestamp = parent.Time()
+	}
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Coinbase:   parent.Coinbase(),
+		Difficulty: parent.Difficulty(),
+		Number:     new(big.Int).Add(parent.Number(), common.Big1),
+		GasLimit:   parent.GasLimit(),
+		Time:       timestamp,
+		Extra:      parent.Extra(),
+		MixDigest:  parent.MixDigest(), // carries the L1 block number
+		BaseFee:    parent.BaseFee(),
+	}
+	var (
+		gasPool  = new(core.GasPool).AddGas(header.GasLimit)
+		gasUsed  uint64
+		txs      types.Transactions
+		receipts types.Receipts
+	)
+	startTx, err := a.startBlockTx(parent, header, statedb)
+	if err != nil {
+		return nil, nil, err
+	}
+	if startTx != nil {
+		statedb.SetTxContext(startTx.Hash(), 0)
+		receipt, _, err := core.ApplyTransaction(bc.Config(), bc, &header.Coinbase, gasPool, statedb, header, startTx, &gasUsed, *bc.GetVMConfig())
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to start the block: %w", err)
+		}
+		txs = append(txs, startTx)
+		receipts = append(receipts, receipt)
+	}
+	for _, entry := range inFlight {
+		statedb.SetTxContext(entry.tx.Hash(), len(txs))
+		snapshot := statedb.Snapshot()
+		receipt, _, err := core.ApplyTransaction(bc.Config(), bc, &header.Coinbase, gasPool, statedb, header, entry.tx, &gasUsed, *bc.GetVMConfig())
+		if err != nil {
+			statedb.RevertToSnapshot(snapshot)
+			log.Trace("Left transaction out of the pending block", "hash", entry.tx.Hash(), "err", err)
+			continue
+		}
+		txs = append(txs, entry.tx)
+		receipts = append(receipts, receipt)
+	}
+	header.GasUsed = gasUsed
+	header.Root = statedb.IntermediateRoot(bc.Config().IsEIP158(header.Number))
+	block := types.NewBlock(header, txs, nil, receipts, trie.NewStackTrie(nil))
+	for _, receipt := range receipts {
+		receipt.BlockHash = block.Hash()
+		for _, txLog := range receipt.Logs {
+			txLog.BlockHash = block.Hash()
+		}
+	}
+	return block, receipts, nil
+}
@@ -0,0 +1,123 @@
+package arbitrum
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/youngqqcn/arbitrum/core"
+	"github.com/youngqqcn/arbitrum/core/types"
+	"github.com/youngqqcn/arbitrum/core/vm"
+)
+
+func TestPendingBlockAndReceipts(t *testing.T) {
+	api, blocks := newTestAPIBackend(t, 2, transferGenerator(t, 1))
+	head := blocks[len(blocks)-1]
+
+	// Without buffered transactions, the pending block is the latest one
+	block, receipts := api.PendingBlockAndReceipts()
+	if block == nil || block.Hash() != head.Hash() || len(receipts) != 1 {
+		t.Fatalf("wrong pending block without buffered transactions: %v, %d receipts", block, len(receipts))
+	}
+
+	// The sender's nonce is 2 after a transfer in each block; nonce 5 leaves a gap and can't be executed yet
+	buffered := []*types.Transaction{signTestTransfer(t, 2), signTestTransfer(t, 3), signTestTransfer(t, 5)}
+	for _, tx := range buffered {
+		if err := api.SendTx(context.Background(), tx); err != nil {
+			t.Fatalf("failed to send tx: %v", err)
+		}
+	}
+	block, receipts = api.PendingBlockAndReceipts()
+	if block == nil {
+		t.Fatal("no pending block with buffered transactions")
+	}
+	if block.NumberU64() != head.NumberU64()+1 || block.ParentHash() != head.Hash() {
+		t.Errorf("pending block %d doesn't follow the head %d", block.NumberU64(), head.NumberU64())
+	}
+	if len(block.Transactions()) != 2 || len(receipts) != 2 {
+		t.Fatalf("wrong pending contents: %d transactions, %d receipts, want 2", len(block.Transactions()), len(receipts))
+	}
+	for i, receipt := range receipts {
+		if receipt.TxHash != buffered[i].Hash() || receipt.Status != types.ReceiptStatusSuccessful {
+			t.Errorf("receipt %d: wrong tx %v or status %d", i, receipt.TxHash, receipt.Status)
+		}
+		if receipt.BlockHash != block.Hash() {
+			t.Errorf("receipt %d: wrong block hash %v, want %v", i, receipt.BlockHash, block.Hash())
+		}
+	}
+	if block.GasUsed() != receipts[1].CumulativeGasUsed {
+		t.Errorf("wrong pending gas used: have %d, want %d", block.GasUsed(), receipts[1].CumulativeGasUsed)
+	}
+	if api.CurrentBlock().Hash() != head.Hash() {
+		t.Error("predicting the pending block changed the head")
+	}
+}
+
+func TestPendingBlockCacheAndLimit(t *testing.T) {
+	api, _ := newTestAPIBackend(t, 1, nil)
+	api.b.config.PendingBlockMaxTxs = 2
+
+	for nonce := uint64(0); nonce < 3; nonce++ {
+		if err := api.SendTx(context.Background(), signTestTransfer(t, nonce)); err != nil {
+			t.Fatalf("failed to send tx: %v", err)
+		}
+	}
+	block, _ := api.PendingBlockAndReceipts()
+	if len(block.Transactions()) != 2 {
+		t.Fatalf("wrong number of pending transactions: have %d, want the limit 2", len(block.Transactions()))
+	}
+	// The prediction is reused while the head and the in-flight transactions stay the same
+	if again, _ := api.PendingBlockAndReceipts(); again != block {
+		t.Error("pending block predicted again for the same head")
+	}
+	api.b.config.PendingBlockMaxTxs = 0
+	if all, _ := api.PendingBlockAndReceipts(); all == block || len(all.Transactions()) != 3 {
+		t.Errorf("pending block not predicted again for changed in-flight transactions")
+	}
+}
+
+// testStartBlockHook ends ArbOS internal transactions right away, the way ArbOS handles them
+type testStartBlockHook struct {
+	vm.TxProcessingHook
+}
+
+func (h *testStartBlockHook) StartTxHook() (bool, uint64, error, []byte) {
+	return true, 0, nil, nil
+}
+
+func TestPendingBlockStartsWithInternalTx(t *testing.T) {
+	api, blocks := newTestAPIBackend(t, 1, nil)
+	head := blocks[len(blocks)-1]
+
+	defer func(hook func(*vm.EVM, core.Message)) { core.ReadyEVMForL2 = hook }(core.ReadyEVMForL2)
+	core.ReadyEVMForL2 = func(evm *vm.EVM, msg core.Message) {
+		if msg.From() == types.ArbosAddress {
+			evm.ProcessingHook = &testStartBlockHook{evm.ProcessingHook}
+		}
+	}
+	defer func(hook func(*big.Int, *big.Int, uint64, *types.Header, *types.Header) *types.ArbitrumInternalTx) {
+		core.InternalTxStartBlock = hook
+	}(core.InternalTxStartBlock)
+	core.InternalTxStartBlock = func(chainId *big.Int, l1BaseFee *big.Int, l1BlockNum uint64, header, lastHeader *types.Header) *types.ArbitrumInternalTx {
+		if lastHeader.Hash() != head.Hash() || header.Number.Uint64() != head.NumberU64()+1 {
+			t.Errorf("start block tx built for block %d after %v", header.Number, lastHeader.Hash())
+		}
+		return &types.ArbitrumInternalTx{ChainId: chainId, Data: []byte{0x01}}
+	}
+
+	transfer := signTestTransfer(t, 0)
+	if err := api.SendTx(context.Background(), transfer); err != nil {
+		t.Fatalf("failed to send tx: %v", err)
+	}
+	block, receipts := api.PendingBlockAndReceipts()
+	txs := block.Transactions()
+	if len(txs) != 2 || len(receipts) != 2 {
+		t.Fatalf("wrong pending contents: %d transactions, %d receipts, want 2", len(txs), len(receipts))
+	}
+	if txs[0].Type() != types.ArbitrumInternalTxType {
+		t.Errorf("pending block starts with a tx of type %d, want the internal start block tx", txs[0].Type())
+	}
+	if txs[1].Hash() != transfer.Hash() || receipts[1].Status != types.ReceiptStatusSuccessful {
+		t.Errorf("in-flight transfer not executed after the start block tx")
+	}
+}
@@ -0,0 +1,205 @@
+package arbitrum
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/youngqqcn/arbitrum/arbitrum_types"
+	"github.com/youngqqcn/arbitrum/common"
+	"github.com/youngqqcn/arbitrum/common/hexutil"
+	"github.com/youngqqcn/arbitrum/core/types"
+	"github.com/youngqqcn/arbitrum/eth/filters"
+	"github.com/youngqqcn/arbitrum/eth/tracers"
+	"github.com/youngqqcn/arbitrum/internal/ethapi"
+	"github.com/youngqqcn/arbitrum/rpc"
+)
+
+// rateLimiterPruneSize is the number of tracked IPs above which expired windows are dropped
+const rateLimiterPruneSize = 1024
+
+type ipWindow struct {
+	start time.Time
+	count uint64
+}
+
+// ipRateLimiter allows each remote IP a fixed number of expensive calls per window
+type ipRateLimiter struct {
+	limit  uint64
+	window time.Duration
+
+	mutex   sync.Mutex
+	windows map[string]*ipWindow
+	now     func() time.Time
+}
+
+func newIPRateLimiter(config RateLimitConfig) *ipRateLimiter {
+	return &ipRateLimiter{
+		limit:   config.Requests,
+		window:  config.Window,
+		windows: make(map[string]*ipWindow),
+		now:     time.Now,
+	}
+}
+
+// remoteIP returns the IP of the client making the call, or "" for in-process calls
+func remoteIP(ctx context.Context) string {
+	addr := rpc.PeerInfoFromContext(ctx).RemoteAddr
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// allow accounts for a call to method and returns a limit exceeded error if the caller is over its budget
+func (l *ipRateLimiter) allow(ctx context.Context, method string) error {
+	ip := remoteIP(ctx)
+	if ip == "" {
+		return nil
+	}
+	now := l.now()
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if len(l.windows) > rateLimiterPruneSize {
+		for key, window := range l.windows {
+			if now.Sub(window.start) >= l.window {
+				delete(l.windows, key)
+			}
+		}
+	}
+	window, ok := l.windows[ip]
+	if !ok || now.Sub(window.start) >= l.window {
+		window = &ipWindow{start: now}
+		l.windows[ip] = window
+	}
+	if window.count >= l.limit {
+		return arbitrum_types.NewLimitExceededError(fmt.Sprintf("rate limit of %d expensive calls per %v exceeded for %s", l.limit, l.window, method))
+	}
+	window.count++
+	return nil
+}
+
+// RateLimitedEthAPI overrides the expensive eth methods, checking the caller's rate limit first
+type RateLimitedEthAPI struct {
+	limiter *ipRateLimiter
+	filters *filters.FilterAPI
+	chain   *ethapi.BlockChainAPI
+}
+
+func (api *RateLimitedEthAPI) GetLogs(ctx context.Context, crit filters.FilterCriteria) ([]*types.Log, error) {
+	if err := api.limiter.allow(ctx, "eth_getLogs"); err != nil {
+		return nil, err
+	}
+	return api.filters.GetLogs(ctx, crit)
+}
+
+func (api *RateLimitedEthAPI) Call(ctx context.Context, args ethapi.TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides *ethapi.StateOverride) (hexutil.Bytes, error) {
+	if err := api.limiter.allow(ctx, "eth_call"); err != nil {
+		return nil, err
+	}
+	return api.chain.Call(ctx, args, blockNrOrHash, overrides)
+}
+
+// RateLimitedDebugAPI overrides every method of the debug tracing API, checking the caller's rate limit first
+type RateLimitedDebugAPI struct {
+	limiter *ipRateLimiter
+	tracers *tracers.API
+}
+
+func (api *RateLimitedDebugAPI) TraceTransaction(ctx context.Context, hash common.Hash, config *tracers.TraceConfig) (interface{}, error) {
+	if err := api.limiter.allow(ctx, "debug_traceTransaction"); err != nil {
+		return nil, err
+	}
+	return api.tracers.TraceTransaction(ctx, hash, config)
+}
+
+func (api *RateLimitedDebugAPI) TraceCall(ctx context.Context, args ethapi.TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, config *tracers.TraceCallConfig) (interface{}, error) {
+	if err := api.limiter.allow(ctx, "debug_traceCall"); err != nil {
+		return nil, err
+	}
+	return api.tracers.TraceCall(ctx, args, blockNrOrHash, config)
+}
+
+func (api *RateLimitedDebugAPI) TraceBlockByNumber(ctx context.Context, number rpc.BlockNumber, config *tracers.TraceConfig) (interface{}, error) {
+	if err := api.limiter.allow(ctx, "debug_traceBlockByNumber"); err != nil {
+		return nil, err
+	}
+	return api.tracers.TraceBlockByNumber(ctx, number, config)
+}
+
+func (api *RateLimitedDebugAPI) TraceBlockByHash(ctx context.Context, hash common.Hash, config *tracers.TraceConfig) (interface{}, error) {
+	if err := api.limiter.allow(ctx, "debug_traceBlockByHash"); err != nil {
+		return nil, err
+	}
+	return api.tracers.TraceBlockByHash(ctx, hash, config)
+}
+
+func (api *RateLimitedDebugAPI) TraceChain(ctx context.Context, start, end rpc.BlockNumber, config *tracers.TraceConfig) (*rpc.Subscription, error) {
+	if err := api.limiter.allow(ctx, "debug_traceChain"); err != nil {
+		return nil, err
+	}
+	return api.tracers.TraceChain(ctx, start, end, config)
+}
+
+func (api *RateLimitedDebugAPI) TraceBlock(ctx context.Context, blob hexutil.Bytes, config *tracers.TraceConfig) (interface{}, error) {
+	if err := api.limiter.allow(ctx, "debug_traceBlock"); err != nil {
+		return nil, err
+	}
+	return api.tracers.TraceBlock(ctx, blob, config)
+}
+
+func (api *RateLimitedDebugAPI) TraceBlockFromFile(ctx context.Context, file string, config *tracers.TraceConfig) (interface{}, error) {
+	if err := api.limiter.allow(ctx, "debug_traceBlockFromFile"); err != nil {
+		return nil, err
+	}
+	return api.tracers.TraceBlockFromFile(ctx, file, config)
+}
+
+func (api *RateLimitedDebugAPI) TraceBadBlock(ctx context.Context, hash common.Hash, config *tracers.TraceConfig) (interface{}, error) {
+	if err := api.limiter.allow(ctx, "debug_traceBadBlock"); err != nil {
+		return nil, err
+	}
+	return api.tracers.TraceBadBlock(ctx, hash, config)
+}
+
+func (api *RateLimitedDebugAPI) StandardTraceBlockToFile(ctx context.Context, hash common.Hash, config *tracers.StdTraceConfig) ([]string, error) {
+	if err := api.limiter.allow(ctx, "debug_standardTraceBlockToFile"); err != nil {
+		return nil, err
+	}
+	return api.tracers.StandardTraceBlockToFile(ctx, hash, config)
+}
+
+func (api *RateLimitedDebugAPI) StandardTraceBadBlockToFile(ctx context.Context, hash common.Hash, config *tracers.StdTraceConfig) ([]string, error) {
+	if err := api.limiter.allow(ctx, "debug_standardTraceBadBlockToFile"); err != nil {
+		return nil, err
+	}
+	return api.tracers.StandardTraceBadBlockToFile(ctx, hash, config)
+}
+
+func (api *RateLimitedDebugAPI) IntermediateRoots(ctx context.Context, hash common.Hash, config *tracers.TraceConfig) ([]common.Hash, error) {
+	if err := api.limiter.allow(ctx, "debug_intermediateRoots"); err != nil {
+		return nil, err
+	}
+	return api.tracers.IntermediateRoots(ctx, hash, config)
+}
+
+// rateLimitedAPIs returns services replacing the expensive methods of filterAPI, eth and debug with rate limited ones.
+// They must be registered after the services they wrap.
+func (a *APIBackend) rateLimitedAPIs(filterAPI *filters.FilterAPI) []rpc.API {
+	limiter := newIPRateLimiter(a.b.config.ExpensiveMethodRateLimits)
+	return []rpc.API{
+		{
+			Namespace: "eth",
+			Version:   "1.0",
+			Service:   &RateLimitedEthAPI{limiter, filterAPI, ethapi.NewBlockChainAPI(a)},
+			Public:    true,
+		},
+		{
+			Namespace: "debug",
+			Service:   &RateLimitedDebugAPI{limiter, tracers.NewAPI(a)},
+		},
+	}
+}
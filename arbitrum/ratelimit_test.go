@@ -0,0 +1,88 @@
+package arbitrum
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/youngqqcn/arbitrum/eth/tracers"
+	"github.com/youngqqcn/arbitrum/rpc"
+)
+
+type testLimitedService struct {
+	limiter *ipRateLimiter
+}
+
+func (s *testLimitedService) Expensive(ctx context.Context) error {
+	return s.limiter.allow(ctx, "test_expensive")
+}
+
+func TestIPRateLimiter(t *testing.T) {
+	limiter := newIPRateLimiter(RateLimitConfig{Requests: 3, Window: time.Minute})
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
+
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("test", &testLimitedService{limiter}); err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+	client, err := rpc.DialHTTP(httpServer.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	for i := 0; i < 3; i++ {
+		if err := client.Call(nil, "test_expensive"); err != nil {
+			t.Fatalf("call %d under the limit failed: %v", i, err)
+		}
+	}
+	err = client.Call(nil, "test_expensive")
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) || rpcErr.ErrorCode() != -32005 {
+		t.Fatalf("expected limit exceeded error, have %v", err)
+	}
+
+	// The budget is restored once the window passes
+	now = now.Add(time.Minute)
+	if err := client.Call(nil, "test_expensive"); err != nil {
+		t.Fatalf("call in new window failed: %v", err)
+	}
+
+	// In-process calls carry no remote address and are never limited
+	for i := 0; i < 5; i++ {
+		if err := limiter.allow(context.Background(), "test_expensive"); err != nil {
+			t.Fatalf("in-process call %d was limited: %v", i, err)
+		}
+	}
+}
+
+func TestRateLimitedDebugAPICoverage(t *testing.T) {
+	// Every method of the tracing API is expensive, so each one must be overridden
+	limited := reflect.TypeOf(&RateLimitedDebugAPI{})
+	api := reflect.TypeOf(&tracers.API{})
+	for i := 0; i < api.NumMethod(); i++ {
+		method := api.Method(i)
+		override, ok := limited.MethodByName(method.Name)
+		if !ok {
+			t.Errorf("debug method %s isn't rate limited", method.Name)
+			continue
+		}
+		// The receiver differs, the arguments must not
+		if override.Type.NumIn() != method.Type.NumIn() {
+			t.Errorf("debug method %s: wrong number of arguments: have %d, want %d", method.Name, override.Type.NumIn()-1, method.Type.NumIn()-1)
+			continue
+		}
+		for j := 1; j < method.Type.NumIn(); j++ {
+			if override.Type.In(j) != method.Type.In(j) {
+				t.Errorf("debug method %s: argument %d is %v, want %v", method.Name, j, override.Type.In(j), method.Type.In(j))
+			}
+		}
+	}
+}
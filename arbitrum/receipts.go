@@ -0,0 +1,105 @@
+package arbitrum
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/youngqqcn/arbitrum/arbitrum_types"
+	"github.com/youngqqcn/arbitrum/common"
+	"github.com/youngqqcn/arbitrum/core/rawdb"
+	"github.com/youngqqcn/arbitrum/core/types"
+	"github.com/youngqqcn/arbitrum/rlp"
+	"github.com/youngqqcn/arbitrum/trie"
+)
+
+// ExportedReceipts is the RLP record written by ExportReceipts for each block
+type ExportedReceipts struct {
+	Number   uint64
+	Hash     common.Hash
+	Missing  bool // the block has transactions but its receipts aren't available, so Receipts is empty
+	Receipts []*types.ReceiptForStorage
+}
+
+// ExportReceipts writes one ExportedReceipts record per canonical block in [start, end] to w, for off-box indexing
+func (a *APIBackend) ExportReceipts(ctx context.Context, start, end uint64, w io.Writer) error {
+	if start > end {
+		return fmt.Errorf("export failed: start (%d) is greater than end (%d)", start, end)
+	}
+	var parentHash common.Hash
+	for number := start; number <= end; number++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		block := a.blockChain().GetBlockByNumber(number)
+		if block == nil {
+			return fmt.Errorf("export failed on #%d: not found", number)
+		}
+		if number > start && block.ParentHash() != parentHash {
+			return fmt.Errorf("export failed: chain reorg during export")
+		}
+		parentHash = block.Hash()
+
+		receipts := a.blockChain().GetReceiptsByHash(block.Hash())
+		entry := ExportedReceipts{
+			Number:   number,
+			Hash:     block.Hash(),
+			Missing:  receipts == nil && len(block.Transactions()) > 0,
+			Receipts: make([]*types.ReceiptForStorage, len(receipts)),
+		}
+		for i, receipt := range receipts {
+			entry.Receipts[i] = (*types.ReceiptForStorage)(receipt)
+		}
+		if err := rlp.Encode(w, &entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetReceiptsForBlocks returns the receipts of each of the given blocks, in the order requested.
+// The number of blocks per request is bounded by ReceiptsMaxBlockCount.
+func (a *APIBackend) GetReceiptsForBlocks(ctx context.Context, blockHashes []common.Hash) ([]types.Receipts, error) {
+	if limit := a.b.config.ReceiptsMaxBlockCount; limit > 0 && uint64(len(blockHashes)) > limit {
+		return nil, arbitrum_types.NewLimitExceededError(fmt.Sprintf("requested receipts of %d blocks, the limit is %d", len(blockHashes), limit))
+	}
+	result := make([]types.Receipts, len(blockHashes))
+	for i, hash := range blockHashes {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		receipts, err := a.GetReceipts(ctx, hash)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = receipts
+	}
+	return result, nil
+}
+
+// GetReceiptsVerified returns the receipts of a block read straight from the database, after checking them against
+// the block header: their derived root must match the header's receipt root, and the last receipt's cumulative
+// gas used must match the gas used the header records. A mismatch points to a corrupted database.
+func (a *APIBackend) GetReceiptsVerified(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	header := a.blockChain().GetHeaderByHash(hash)
+	if header == nil {
+		return nil, errors.New("header not found")
+	}
+	number := header.Number.Uint64()
+	receipts := rawdb.ReadReceipts(a.ChainDb(), hash, number, a.ChainConfig())
+	if receipts == nil {
+		return nil, fmt.Errorf("receipts of block %d not found", number)
+	}
+	if root := types.DeriveSha(receipts, trie.NewStackTrie(nil)); root != header.ReceiptHash {
+		return nil, fmt.Errorf("block %d: receipts root %v, header records %v", number, root, header.ReceiptHash)
+	}
+	var cumulativeGasUsed uint64
+	if len(receipts) > 0 {
+		cumulativeGasUsed = receipts[len(receipts)-1].CumulativeGasUsed
+	}
+	if cumulativeGasUsed != header.GasUsed {
+		return nil, fmt.Errorf("block %d: receipts use %d gas, header records %d", number, cumulativeGasUsed, header.GasUsed)
+	}
+	return receipts, nil
+}
@@ -0,0 +1,129 @@
+package arbitrum
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/youngqqcn/arbitrum/common"
+	"github.com/youngqqcn/arbitrum/core/rawdb"
+	"github.com/youngqqcn/arbitrum/core/types"
+	"github.com/youngqqcn/arbitrum/rlp"
+	"github.com/youngqqcn/arbitrum/rpc"
+)
+
+func TestExportReceipts(t *testing.T) {
+	api, blocks := newTestAPIBackend(t, 4, transferGenerator(t, 2))
+
+	// Drop the receipts of one block to check they're marked as missing
+	pruned := blocks[2]
+	rawdb.DeleteReceipts(api.ChainDb(), pruned.Hash(), pruned.NumberU64())
+
+	var buf bytes.Buffer
+	if err := api.ExportReceipts(context.Background(), 1, 4, &buf); err != nil {
+		t.Fatalf("failed to export receipts: %v", err)
+	}
+	stream := rlp.NewStream(&buf, 0)
+	for _, block := range blocks {
+		var entry ExportedReceipts
+		if err := stream.Decode(&entry); err != nil {
+			t.Fatalf("block %d: failed to decode exported receipts: %v", block.NumberU64(), err)
+		}
+		if entry.Number != block.NumberU64() || entry.Hash != block.Hash() {
+			t.Fatalf("wrong block exported: have %d (%v), want %d (%v)", entry.Number, entry.Hash, block.NumberU64(), block.Hash())
+		}
+		if block.Hash() == pruned.Hash() {
+			if !entry.Missing || len(entry.Receipts) != 0 {
+				t.Errorf("block %d: pruned receipts not marked missing", entry.Number)
+			}
+			continue
+		}
+		want, err := api.GetReceipts(context.Background(), block.Hash())
+		if err != nil {
+			t.Fatalf("failed to get receipts: %v", err)
+		}
+		if entry.Missing || len(entry.Receipts) != len(want) {
+			t.Fatalf("block %d: wrong number of receipts: have %d, want %d", entry.Number, len(entry.Receipts), len(want))
+		}
+		for i, receipt := range entry.Receipts {
+			if receipt.Status != want[i].Status || receipt.CumulativeGasUsed != want[i].CumulativeGasUsed || len(receipt.Logs) != len(want[i].Logs) {
+				t.Errorf("block %d receipt %d: exported receipt doesn't match", entry.Number, i)
+			}
+		}
+	}
+	if err := stream.Decode(new(ExportedReceipts)); !errors.Is(err, io.EOF) {
+		t.Errorf("expected end of export, have %v", err)
+	}
+}
+
+func TestGetReceiptsForBlocks(t *testing.T) {
+	api, blocks := newTestAPIBackend(t, 4, transferGenerator(t, 2))
+
+	hashes := []common.Hash{blocks[3].Hash(), blocks[0].Hash(), blocks[2].Hash()}
+	result, err := api.GetReceiptsForBlocks(context.Background(), hashes)
+	if err != nil {
+		t.Fatalf("failed to get receipts: %v", err)
+	}
+	if len(result) != len(hashes) {
+		t.Fatalf("wrong number of receipt sets: have %d, want %d", len(result), len(hashes))
+	}
+	for i, hash := range hashes {
+		want, err := api.GetReceipts(context.Background(), hash)
+		if err != nil {
+			t.Fatalf("failed to get receipts: %v", err)
+		}
+		if len(result[i]) != len(want) {
+			t.Fatalf("block %v: wrong number of receipts: have %d, want %d", hash, len(result[i]), len(want))
+		}
+		for j := range want {
+			if result[i][j].TxHash != want[j].TxHash || result[i][j].BlockHash != hash {
+				t.Errorf("block %v receipt %d: wrong receipt", hash, j)
+			}
+		}
+	}
+
+	api.b.config.ReceiptsMaxBlockCount = 2
+	_, err = api.GetReceiptsForBlocks(context.Background(), hashes)
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) || rpcErr.ErrorCode() != -32005 {
+		t.Errorf("expected limit exceeded error, have %v", err)
+	}
+}
+
+func TestGetReceiptsVerified(t *testing.T) {
+	api, blocks := newTestAPIBackend(t, 3, transferGenerator(t, 3))
+
+	for _, block := range blocks {
+		receipts, err := api.GetReceiptsVerified(context.Background(), block.Hash())
+		if err != nil {
+			t.Fatalf("block %d: failed to verify receipts: %v", block.NumberU64(), err)
+		}
+		if len(receipts) != len(block.Transactions()) {
+			t.Errorf("block %d: wrong number of receipts: have %d, want %d", block.NumberU64(), len(receipts), len(block.Transactions()))
+		}
+	}
+
+	// Corrupt the cumulative gas of one receipt in the middle and of the last one
+	for _, index := range []int{1, 2} {
+		block := blocks[len(blocks)-1]
+		receipts := rawdb.ReadRawReceipts(api.ChainDb(), block.Hash(), block.NumberU64())
+		receipts[index].CumulativeGasUsed += 1_000_000
+		rawdb.WriteReceipts(api.ChainDb(), block.Hash(), block.NumberU64(), receipts)
+		if _, err := api.GetReceiptsVerified(context.Background(), block.Hash()); err == nil {
+			t.Errorf("corrupted cumulative gas of receipt %d not detected", index)
+		}
+		receipts[index].CumulativeGasUsed -= 1_000_000
+		rawdb.WriteReceipts(api.ChainDb(), block.Hash(), block.NumberU64(), receipts)
+	}
+
+	// A corrupted status doesn't change any gas, but no longer matches the receipt root
+	block := blocks[len(blocks)-1]
+	receipts := rawdb.ReadRawReceipts(api.ChainDb(), block.Hash(), block.NumberU64())
+	receipts[0].Status = types.ReceiptStatusFailed
+	rawdb.WriteReceipts(api.ChainDb(), block.Hash(), block.NumberU64(), receipts)
+	if _, err := api.GetReceiptsVerified(context.Background(), block.Hash()); err == nil {
+		t.Error("corrupted receipt status not detected")
+	}
+}
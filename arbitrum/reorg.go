@@ -0,0 +1,193 @@
+package arbitrum
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/youngqqcn/arbitrum/common"
+	"github.com/youngqqcn/arbitrum/common/hexutil"
+	"github.com/youngqqcn/arbitrum/core"
+	"github.com/youngqqcn/arbitrum/core/types"
+	"github.com/youngqqcn/arbitrum/event"
+	"github.com/youngqqcn/arbitrum/log"
+)
+
+const (
+	// chainHeadChanSize is the size of channel listening to ChainHeadEvent.
+	chainHeadChanSize = 10
+
+	// recentReorgsSize is the number of reorgs remembered for GetRecentReorgs
+	recentReorgsSize = 64
+)
+
+// DeepReorgEvent is posted when the canonical head moves to a block that does not extend the previous head
+type DeepReorgEvent struct {
+	OldHead *types.Header
+	NewHead *types.Header
+	Depth   uint64 // number of blocks dropped from the old canonical chain
+}
+
+// reorgDepth returns how many blocks of oldHead's chain are no longer canonical once newHead is the head.
+// A newHead that extends oldHead yields a depth of 0.
+func (a *APIBackend) reorgDepth(oldHead, newHead *types.Header) (uint64, bool) {
+	if oldHead == nil || newHead == nil {
+		return 0, false
+	}
+	if newHead.ParentHash == oldHead.Hash() || newHead.Hash() == oldHead.Hash() {
+		return 0, true
+	}
+	bc := a.blockChain()
+	oldAncestor, newAncestor := oldHead, newHead
+	for oldAncestor.Number.Uint64() > newAncestor.Number.Uint64() {
+		if oldAncestor = bc.GetHeader(oldAncestor.ParentHash, oldAncestor.Number.Uint64()-1); oldAncestor == nil {
+			return 0, false
+		}
+	}
+	for newAncestor.Number.Uint64() > oldAncestor.Number.Uint64() {
+		if newAncestor = bc.GetHeader(newAncestor.ParentHash, newAncestor.Number.Uint64()-1); newAncestor == nil {
+			return 0, false
+		}
+	}
+	for oldAncestor.Hash() != newAncestor.Hash() {
+		if oldAncestor.Number.Uint64() == 0 {
+			return 0, false
+		}
+		oldAncestor = bc.GetHeader(oldAncestor.ParentHash, oldAncestor.Number.Uint64()-1)
+		newAncestor = bc.GetHeader(newAncestor.ParentHash, newAncestor.Number.Uint64()-1)
+		if oldAncestor == nil || newAncestor == nil {
+			return 0, false
+		}
+	}
+	return oldHead.Number.Uint64() - oldAncestor.Number.Uint64(), true
+}
+
+// SubscribeDeepReorg watches the chain head and posts a DeepReorgEvent for every reorg dropping at least minDepth blocks.
+// Events are queued internally, so a slow consumer doesn't hold up the head feed; a consumer falling behind
+// by more than SubscriptionQueueLimit events is unsubscribed with ErrSubscriptionQueueFull.
+func (a *APIBackend) SubscribeDeepReorg(ch chan<- DeepReorgEvent, minDepth uint64) event.Subscription {
+	if minDepth == 0 {
+		minDepth = 1
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		headCh := make(chan core.ChainHeadEvent, chainHeadChanSize)
+		headSub := a.SubscribeChainHeadEvent(headCh)
+		defer headSub.Unsubscribe()
+
+		prevHead := a.CurrentHeader()
+		var queue []DeepReorgEvent
+		for {
+			var (
+				out  chan<- DeepReorgEvent
+				next DeepReorgEvent
+			)
+			if len(queue) > 0 {
+				out, next = ch, queue[0]
+			}
+			select {
+			case ev := <-headCh:
+				newHead := ev.Block.Header()
+				depth, ok := a.reorgDepth(prevHead, newHead)
+				if ok && depth >= minDepth {
+					if a.queueFull(len(queue)) {
+						return ErrSubscriptionQueueFull
+					}
+					queue = append(queue, DeepReorgEvent{OldHead: prevHead, NewHead: newHead, Depth: depth})
+				}
+				prevHead = newHead
+			case out <- next:
+				queue = queue[1:]
+			case err := <-headSub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	})
+}
+
+// ReorgRecord describes a reorg observed by the node
+type ReorgRecord struct {
+	OldHead       common.Hash    `json:"oldHead"`
+	OldHeadNumber hexutil.Uint64 `json:"oldHeadNumber"`
+	NewHead       common.Hash    `json:"newHead"`
+	NewHeadNumber hexutil.Uint64 `json:"newHeadNumber"`
+	Depth         hexutil.Uint64 `json:"depth"`
+	Timestamp     hexutil.Uint64 `json:"timestamp"` // unix time the reorg was observed at
+}
+
+// reorgHistory is a ring buffer of the most recent reorgs
+type reorgHistory struct {
+	mu      sync.Mutex
+	records []ReorgRecord
+	next    int // index the next record is written at once the buffer is full
+}
+
+func newReorgHistory(size int) *reorgHistory {
+	return &reorgHistory{records: make([]ReorgRecord, 0, size)}
+}
+
+func (h *reorgHistory) add(record ReorgRecord) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.records) < cap(h.records) {
+		h.records = append(h.records, record)
+		return
+	}
+	h.records[h.next] = record
+	h.next = (h.next + 1) % len(h.records)
+}
+
+// recent returns up to count records, newest first
+func (h *reorgHistory) recent(count int) []ReorgRecord {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if count > len(h.records) {
+		count = len(h.records)
+	}
+	records := make([]ReorgRecord, 0, count)
+	newest := h.next - 1
+	if len(h.records) < cap(h.records) {
+		newest = len(h.records) - 1
+	}
+	for i := 0; i < count; i++ {
+		records = append(records, h.records[(newest-i+len(h.records))%len(h.records)])
+	}
+	return records
+}
+
+// recordReorgs keeps the history of recent reorgs up to date until the backend stops
+func (b *Backend) recordReorgs() {
+	ch := make(chan DeepReorgEvent, chainHeadChanSize)
+	sub := b.apiBackend.SubscribeDeepReorg(ch, 1)
+	defer sub.Unsubscribe()
+	for {
+		select {
+		case ev := <-ch:
+			b.recentReorgs.add(ReorgRecord{
+				OldHead:       ev.OldHead.Hash(),
+				OldHeadNumber: hexutil.Uint64(ev.OldHead.Number.Uint64()),
+				NewHead:       ev.NewHead.Hash(),
+				NewHeadNumber: hexutil.Uint64(ev.NewHead.Number.Uint64()),
+				Depth:         hexutil.Uint64(ev.Depth),
+				Timestamp:     hexutil.Uint64(time.Now().Unix()),
+			})
+		case err := <-sub.Err():
+			if err != nil {
+				log.Error("Stopped recording reorgs", "err", err)
+			}
+			return
+		case <-b.chanClose:
+			return
+		}
+	}
+}
+
+// GetRecentReorgs returns up to the last count reorgs observed since the node started, newest first
+func (a *APIBackend) GetRecentReorgs(ctx context.Context, count int) ([]ReorgRecord, error) {
+	if count <= 0 {
+		return nil, errors.New("count must be positive")
+	}
+	return a.b.recentReorgs.recent(count), nil
+}
@@ -0,0 +1,152 @@
+package arbitrum
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/youngqqcn/arbitrum/common/hexutil"
+)
+
+func TestSubscribeDeepReorg(t *testing.T) {
+	api, blocks := newTestAPIBackend(t, 8, nil)
+
+	ch := make(chan DeepReorgEvent, 1)
+	sub := api.SubscribeDeepReorg(ch, 3)
+	defer sub.Unsubscribe()
+	// Give the subscription goroutine time to register on the head feed
+	time.Sleep(50 * time.Millisecond)
+
+	// Rewinding a single block is shallow and must not trigger an alert
+	if err := api.blockChain().ReorgToOldBlock(blocks[6]); err != nil {
+		t.Fatalf("failed to reorg: %v", err)
+	}
+	select {
+	case ev := <-ch:
+		t.Fatalf("unexpected event for shallow reorg: depth %d", ev.Depth)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Rewinding from block 7 to block 2 drops five blocks
+	if err := api.blockChain().ReorgToOldBlock(blocks[1]); err != nil {
+		t.Fatalf("failed to reorg: %v", err)
+	}
+	select {
+	case ev := <-ch:
+		if ev.Depth != 5 {
+			t.Errorf("wrong reorg depth: have %d, want 5", ev.Depth)
+		}
+		if ev.OldHead.Hash() != blocks[6].Hash() {
+			t.Errorf("wrong old head: have %v, want %v", ev.OldHead.Hash(), blocks[6].Hash())
+		}
+		if ev.NewHead.Hash() != blocks[1].Hash() {
+			t.Errorf("wrong new head: have %v, want %v", ev.NewHead.Hash(), blocks[1].Hash())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("missing deep reorg event")
+	}
+}
+
+func TestSubscribeDeepReorgQueue(t *testing.T) {
+	api, blocks := newTestAPIBackend(t, 8, nil)
+	api.b.config.SubscriptionQueueLimit = 2
+
+	ch := make(chan DeepReorgEvent)
+	sub := api.SubscribeDeepReorg(ch, 1)
+	defer sub.Unsubscribe()
+	time.Sleep(50 * time.Millisecond)
+
+	// Events queue up while the consumer isn't reading, without holding up the head feed
+	for _, block := range []int{5, 3} {
+		if err := api.blockChain().ReorgToOldBlock(blocks[block]); err != nil {
+			t.Fatalf("failed to reorg: %v", err)
+		}
+	}
+	for i, want := range []uint64{2, 2} {
+		select {
+		case ev := <-ch:
+			if ev.Depth != want {
+				t.Errorf("event %d: wrong reorg depth: have %d, want %d", i, ev.Depth, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("event %d not delivered", i)
+		}
+	}
+
+	// A consumer falling behind by more than the limit is unsubscribed
+	for _, block := range []int{2, 1, 0} {
+		if err := api.blockChain().ReorgToOldBlock(blocks[block]); err != nil {
+			t.Fatalf("failed to reorg: %v", err)
+		}
+	}
+	select {
+	case err := <-sub.Err():
+		if !errors.Is(err, ErrSubscriptionQueueFull) {
+			t.Errorf("wrong error: have %v, want %v", err, ErrSubscriptionQueueFull)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("slow subscriber not unsubscribed")
+	}
+}
+
+func TestGetRecentReorgs(t *testing.T) {
+	api, blocks := newTestAPIBackend(t, 8, nil)
+	go api.b.recordReorgs()
+	defer close(api.b.chanClose)
+	// Give the recorder time to register on the head feed
+	time.Sleep(50 * time.Millisecond)
+
+	if err := api.blockChain().ReorgToOldBlock(blocks[6]); err != nil {
+		t.Fatalf("failed to reorg: %v", err)
+	}
+	if err := api.blockChain().ReorgToOldBlock(blocks[1]); err != nil {
+		t.Fatalf("failed to reorg: %v", err)
+	}
+
+	var reorgs []ReorgRecord
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		var err error
+		if reorgs, err = api.GetRecentReorgs(context.Background(), 10); err != nil {
+			t.Fatal(err)
+		}
+		if len(reorgs) == 2 {
+			break
+		}
+	}
+	if len(reorgs) != 2 {
+		t.Fatalf("got %d reorgs, want 2", len(reorgs))
+	}
+	if reorgs[0].OldHead != blocks[6].Hash() || reorgs[0].NewHead != blocks[1].Hash() || reorgs[0].Depth != 5 {
+		t.Errorf("wrong newest reorg: %+v", reorgs[0])
+	}
+	if reorgs[1].OldHead != blocks[7].Hash() || reorgs[1].NewHead != blocks[6].Hash() || reorgs[1].Depth != 1 {
+		t.Errorf("wrong oldest reorg: %+v", reorgs[1])
+	}
+	if reorgs[0].Timestamp == 0 {
+		t.Error("missing reorg timestamp")
+	}
+
+	if limited, _ := api.GetRecentReorgs(context.Background(), 1); len(limited) != 1 || limited[0] != reorgs[0] {
+		t.Errorf("wrong limited reorgs: %+v", limited)
+	}
+	if _, err := api.GetRecentReorgs(context.Background(), 0); err == nil {
+		t.Error("expected an error for a zero count")
+	}
+}
+
+func TestReorgHistoryWrapsAround(t *testing.T) {
+	history := newReorgHistory(3)
+	for depth := uint64(1); depth <= 5; depth++ {
+		history.add(ReorgRecord{Depth: hexutil.Uint64(depth)})
+	}
+	records := history.recent(10)
+	if len(records) != 3 {
+		t.Fatalf("got %d records, want 3", len(records))
+	}
+	for i, want := range []hexutil.Uint64{5, 4, 3} {
+		if records[i].Depth != want {
+			t.Errorf("record %d: have depth %d, want %d", i, records[i].Depth, want)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package arbitrum
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/youngqqcn/arbitrum/core/types"
+	"github.com/youngqqcn/arbitrum/log"
+	"github.com/youngqqcn/arbitrum/rpc"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+// requestID returns the X-Request-ID the client sent with the request being served, if any
+func requestID(ctx context.Context) string {
+	return rpc.PeerInfoFromContext(ctx).HTTP.RequestID
+}
+
+// withRequestID appends the request's ID to a log context, so a slow request can be followed through the node
+func withRequestID(ctx context.Context, logCtx ...interface{}) []interface{} {
+	if id := requestID(ctx); id != "" {
+		return append(logCtx, "requestID", id)
+	}
+	return logCtx
+}
+
+// requestIDFallbackClient forwards the request's ID to the classic fallback,
+// so a request can be correlated across both nodes
+type requestIDFallbackClient struct {
+	impl types.FallbackClient
+}
+
+func (c *requestIDFallbackClient) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	log.Debug("Forwarding request to fallback client", withRequestID(ctx, "method", method)...)
+	if id := requestID(ctx); id != "" {
+		ctx = rpc.NewContextWithHeaders(ctx, http.Header{requestIDHeader: []string{id}})
+	}
+	return c.impl.CallContext(ctx, result, method, args...)
+}
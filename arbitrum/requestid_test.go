@@ -0,0 +1,115 @@
+package arbitrum
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/youngqqcn/arbitrum/common"
+	"github.com/youngqqcn/arbitrum/core/types"
+	"github.com/youngqqcn/arbitrum/log"
+	"github.com/youngqqcn/arbitrum/rpc"
+)
+
+type testFallbackService struct{}
+
+func (s *testFallbackService) Echo(value string) string {
+	return value
+}
+
+// testRequestIDService serves a request through several layers of the backend
+type testRequestIDService struct {
+	api *APIBackend
+}
+
+func (s *testRequestIDService) Serve(ctx context.Context) error {
+	head := s.api.CurrentHeader()
+	if _, _, _, _, err := s.api.FeeHistory(ctx, 1, rpc.LatestBlockNumber, nil); err != nil {
+		return err
+	}
+	if _, err := s.api.GetLogs(ctx, head.Hash(), head.Number.Uint64()); err != nil {
+		return err
+	}
+	statedb, header, err := s.api.StateAndHeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if err != nil {
+		return err
+	}
+	msg := types.NewMessage(testAddr, &common.Address{0xaa}, 0, common.Big0, 21000, common.Big0, common.Big0, common.Big0, nil, nil, true)
+	if _, _, err := s.api.GetEVM(ctx, msg, statedb, header, nil); err != nil {
+		return err
+	}
+	var echo string
+	return s.api.FallbackClient().CallContext(ctx, &echo, "test_echo", "hello")
+}
+
+func TestRequestIDTracing(t *testing.T) {
+	setTestSpeedLimit(t, 7_000_000)
+	api, _ := newTestAPIBackend(t, 2, transferGenerator(t, 1))
+
+	var (
+		mu         sync.Mutex
+		fallbackID string
+		logged     = make(map[string]bool)
+	)
+	fallbackServer := rpc.NewServer()
+	defer fallbackServer.Stop()
+	if err := fallbackServer.RegisterName("test", new(testFallbackService)); err != nil {
+		t.Fatal(err)
+	}
+	fallbackHTTP := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		fallbackID = r.Header.Get(requestIDHeader)
+		mu.Unlock()
+		fallbackServer.ServeHTTP(w, r)
+	}))
+	defer fallbackHTTP.Close()
+	fallbackClient, err := CreateFallbackClient(fallbackHTTP.URL, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api.fallbackClient = fallbackClient
+
+	prevHandler := log.Root().GetHandler()
+	defer log.Root().SetHandler(prevHandler)
+	log.Root().SetHandler(log.FuncHandler(func(r *log.Record) error {
+		for i := 0; i+1 < len(r.Ctx); i += 2 {
+			if r.Ctx[i] == "requestID" && r.Ctx[i+1] == "test-request-1" {
+				mu.Lock()
+				logged[r.Msg] = true
+				mu.Unlock()
+			}
+		}
+		return nil
+	}))
+
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("test", &testRequestIDService{api}); err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+	client, err := rpc.DialHTTP(httpServer.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	ctx := rpc.NewContextWithHeaders(context.Background(), http.Header{requestIDHeader: []string{"test-request-1"}})
+	if err := client.CallContext(ctx, nil, "test_serve"); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, msg := range []string{"Serving fee history", "Reading block logs", "Creating EVM", "Forwarding request to fallback client"} {
+		if !logged[msg] {
+			t.Errorf("request ID missing from log %q", msg)
+		}
+	}
+	if fallbackID != "test-request-1" {
+		t.Errorf("wrong request ID forwarded to fallback: have %q, want %q", fallbackID, "test-request-1")
+	}
+}
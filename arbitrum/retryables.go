@@ -0,0 +1,35 @@
+package arbitrum
+
+import (
+	"context"
+	"errors"
+
+	"github.com/youngqqcn/arbitrum/common"
+	"github.com/youngqqcn/arbitrum/core/types"
+)
+
+// IsAutoRedeem reports whether a transaction is the redeem ArbOS scheduled automatically when its retryable was submitted,
+// and returns the ticket of the retryable a retry transaction redeems. Manual redeems return their ticket too.
+func (a *APIBackend) IsAutoRedeem(ctx context.Context, txHash common.Hash) (bool, common.Hash, error) {
+	tx, blockHash, _, index, err := a.GetTransaction(ctx, txHash)
+	if err != nil {
+		return false, common.Hash{}, err
+	}
+	if tx == nil {
+		return false, common.Hash{}, errors.New("transaction not found")
+	}
+	retry, ok := tx.GetInner().(*types.ArbitrumRetryTx)
+	if !ok {
+		return false, common.Hash{}, nil
+	}
+	// The auto-redeem is the first redeem attempt, so its nonce is zero, and it directly follows the submission,
+	// whose hash is the ticket. Manual redeems have later nonces, even when they land in the same block.
+	if retry.Nonce != 0 {
+		return false, retry.TicketId, nil
+	}
+	_, ticketBlockHash, _, ticketIndex, err := a.GetTransaction(ctx, retry.TicketId)
+	if err != nil {
+		return false, common.Hash{}, err
+	}
+	return ticketBlockHash == blockHash && ticketIndex+1 == index, retry.TicketId, nil
+}
@@ -0,0 +1,85 @@
+package arbitrum
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/youngqqcn/arbitrum/common"
+	"github.com/youngqqcn/arbitrum/core/rawdb"
+	"github.com/youngqqcn/arbitrum/core/types"
+)
+
+// writeTestBlock stores a canonical block with the given transactions directly in the database,
+// bypassing execution so that ArbOS-only transaction types can be looked up
+func writeTestBlock(t *testing.T, api *APIBackend, number uint64, txs []*types.Transaction) {
+	t.Helper()
+	header := &types.Header{Number: new(big.Int).SetUint64(number), Difficulty: common.Big1, BaseFee: common.Big1}
+	block := types.NewBlockWithHeader(header).WithBody(txs, nil)
+	rawdb.WriteBlock(api.ChainDb(), block)
+	rawdb.WriteCanonicalHash(api.ChainDb(), block.Hash(), number)
+	rawdb.WriteTxLookupEntriesByBlock(api.ChainDb(), block)
+}
+
+func TestIsAutoRedeem(t *testing.T) {
+	api, _ := newTestAPIBackend(t, 1, nil)
+	chainId := api.ChainConfig().ChainID
+	to := common.Address{0xaa}
+
+	submission := types.NewTx(&types.ArbitrumSubmitRetryableTx{
+		ChainId:          chainId,
+		RequestId:        common.Hash{1},
+		From:             testAddr,
+		L1BaseFee:        common.Big1,
+		DepositValue:     big.NewInt(1e18),
+		GasFeeCap:        common.Big1,
+		Gas:              100000,
+		RetryTo:          &to,
+		RetryValue:       common.Big0,
+		MaxSubmissionFee: common.Big1,
+	})
+	retry := func(nonce uint64) *types.Transaction {
+		return types.NewTx(&types.ArbitrumRetryTx{
+			ChainId:             chainId,
+			Nonce:               nonce,
+			From:                testAddr,
+			GasFeeCap:           common.Big1,
+			Gas:                 100000,
+			To:                  &to,
+			Value:               common.Big0,
+			TicketId:            submission.Hash(),
+			MaxRefund:           common.Big0,
+			SubmissionFeeRefund: common.Big0,
+		})
+	}
+	// After a failed auto-redeem, a manual redeem can still land in the ticket's block
+	autoRedeem, sameBlockRedeem, manualRedeem := retry(0), retry(1), retry(2)
+	normal := signTestTransfer(t, 0)
+	writeTestBlock(t, api, 100, []*types.Transaction{submission, autoRedeem, sameBlockRedeem, normal})
+	writeTestBlock(t, api, 101, []*types.Transaction{manualRedeem})
+
+	tests := []struct {
+		name       string
+		tx         *types.Transaction
+		wantAuto   bool
+		wantTicket common.Hash
+	}{
+		{"auto-redeem", autoRedeem, true, submission.Hash()},
+		{"manual redeem in the ticket's block", sameBlockRedeem, false, submission.Hash()},
+		{"manual redeem", manualRedeem, false, submission.Hash()},
+		{"submission", submission, false, common.Hash{}},
+		{"normal", normal, false, common.Hash{}},
+	}
+	for _, tt := range tests {
+		auto, ticket, err := api.IsAutoRedeem(context.Background(), tt.tx.Hash())
+		if err != nil {
+			t.Fatalf("%s: failed to check auto-redeem: %v", tt.name, err)
+		}
+		if auto != tt.wantAuto || ticket != tt.wantTicket {
+			t.Errorf("%s: have auto-redeem %v of ticket %v, want %v of %v", tt.name, auto, ticket, tt.wantAuto, tt.wantTicket)
+		}
+	}
+	if _, _, err := api.IsAutoRedeem(context.Background(), common.Hash{0xff}); err == nil {
+		t.Error("expected error for unknown transaction")
+	}
+}
@@ -0,0 +1,92 @@
+package arbitrum
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/youngqqcn/arbitrum/common"
+	"github.com/youngqqcn/arbitrum/core/types"
+	"github.com/youngqqcn/arbitrum/core/vm"
+	"github.com/youngqqcn/arbitrum/internal/ethapi"
+	"github.com/youngqqcn/arbitrum/rpc"
+)
+
+// GetCodeHash returns the hash of an account's code at the given block,
+// which is cheaper than fetching the code to detect a contract or verify its bytecode
+func (a *APIBackend) GetCodeHash(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (common.Hash, error) {
+	statedb, _, err := a.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if statedb == nil || err != nil {
+		return common.Hash{}, err
+	}
+	return statedb.GetCodeHash(address), statedb.Error()
+}
+
+// HasState reports whether the state of the given block is available locally, without re-executing any blocks.
+// Blocks from before the Nitro genesis never have local state.
+func (a *APIBackend) HasState(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (bool, error) {
+	header, err := a.HeaderByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return false, err
+	}
+	if header == nil {
+		return false, errors.New("header not found")
+	}
+	if !a.ChainConfig().IsArbitrumNitro(header.Number) {
+		return false, nil
+	}
+	return a.blockChain().HasState(header.Root), nil
+}
+
+// GetBlockStateRoots returns the state roots before and after a block's transactions executed,
+// which are its parent's state root and its own. The genesis block starts from the empty state.
+func (a *APIBackend) GetBlockStateRoots(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (pre, post common.Hash, err error) {
+	header, err := a.HeaderByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return common.Hash{}, common.Hash{}, err
+	}
+	if header == nil {
+		return common.Hash{}, common.Hash{}, errors.New("header not found")
+	}
+	if header.Number.Sign() == 0 {
+		return types.EmptyRootHash, header.Root, nil
+	}
+	parent, err := a.HeaderByHash(ctx, header.ParentHash)
+	if err != nil {
+		return common.Hash{}, common.Hash{}, err
+	}
+	if parent == nil {
+		return common.Hash{}, common.Hash{}, fmt.Errorf("parent of block %d not found", header.Number)
+	}
+	return parent.Root, header.Root, nil
+}
+
+// GetProof returns the Merkle proof of an account and of the given slots of its storage at a block, as served by eth_getProof.
+// Accounts that don't exist get zeroed fields, and their slots get empty proofs.
+func (a *APIBackend) GetProof(ctx context.Context, address common.Address, storageKeys []common.Hash, blockNrOrHash rpc.BlockNumberOrHash) (*ethapi.AccountResult, error) {
+	keys := make([]string, len(storageKeys))
+	for i, key := range storageKeys {
+		keys[i] = key.Hex()
+	}
+	return ethapi.NewBlockChainAPI(a).GetProof(ctx, address, keys, blockNrOrHash)
+}
+
+// IsArbosPrecompile reports whether an address is one of the precompiles active at a block,
+// which depend on the block's ArbOS version.
+func (a *APIBackend) IsArbosPrecompile(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (bool, error) {
+	header, err := a.HeaderByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return false, err
+	}
+	if header == nil {
+		return false, errors.New("header not found")
+	}
+	arbosVersion := types.DeserializeHeaderExtraInformation(header).ArbOSFormatVersion
+	rules := a.ChainConfig().Rules(header.Number, header.Difficulty.Sign() == 0, header.Time, arbosVersion)
+	for _, precompile := range vm.ActivePrecompiles(rules) {
+		if precompile == address {
+			return true, nil
+		}
+	}
+	return false, nil
+}
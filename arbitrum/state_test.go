@@ -0,0 +1,209 @@
+package arbitrum
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/youngqqcn/arbitrum/common"
+	"github.com/youngqqcn/arbitrum/core"
+	"github.com/youngqqcn/arbitrum/core/types"
+	"github.com/youngqqcn/arbitrum/core/vm"
+	"github.com/youngqqcn/arbitrum/crypto"
+	"github.com/youngqqcn/arbitrum/ethdb/memorydb"
+	"github.com/youngqqcn/arbitrum/rpc"
+	"github.com/youngqqcn/arbitrum/trie"
+)
+
+func TestGetCodeHash(t *testing.T) {
+	api, _ := newTestAPIBackendWithAlloc(t, testEmitterAlloc, 1, nil)
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+
+	eoaHash, err := api.GetCodeHash(context.Background(), testAddr, latest)
+	if err != nil {
+		t.Fatalf("failed to get code hash: %v", err)
+	}
+	if eoaHash != types.EmptyCodeHash {
+		t.Errorf("wrong eoa code hash: have %v, want %v", eoaHash, types.EmptyCodeHash)
+	}
+
+	contractHash, err := api.GetCodeHash(context.Background(), testEmitter, latest)
+	if err != nil {
+		t.Fatalf("failed to get code hash: %v", err)
+	}
+	if want := crypto.Keccak256Hash(testEmitterAlloc[testEmitter].Code); contractHash != want {
+		t.Errorf("wrong contract code hash: have %v, want %v", contractHash, want)
+	}
+}
+
+func TestStateAtBlockMaxRewind(t *testing.T) {
+	api, blocks := newPrunedTestAPIBackend(t, 2, 10, transferGenerator(t, 1))
+	old := blocks[4]
+	if api.blockChain().HasState(old.Root()) {
+		t.Fatalf("state of block %d wasn't pruned", old.NumberU64())
+	}
+
+	api.b.config.StateAtBlockMaxRewind = 2
+	if _, _, err := api.StateAtBlock(context.Background(), old, 128, nil, true, false); !errors.Is(err, ErrStateTooOld) {
+		t.Fatalf("wrong error for too old block: have %v, want %v", err, ErrStateTooOld)
+	}
+	head := blocks[len(blocks)-1]
+	_, release, err := api.StateAtBlock(context.Background(), head, 128, nil, true, false)
+	if err != nil {
+		t.Fatalf("failed to get state of head block: %v", err)
+	}
+	release()
+
+	// Without a bound, the state is recreated from the genesis
+	api.b.config.StateAtBlockMaxRewind = 0
+	statedb, release, err := api.StateAtBlock(context.Background(), old, 128, nil, true, false)
+	if err != nil {
+		t.Fatalf("failed to recreate state of old block: %v", err)
+	}
+	defer release()
+	if root := statedb.IntermediateRoot(true); root != old.Root() {
+		t.Errorf("wrong recreated state root: have %v, want %v", root, old.Root())
+	}
+}
+
+func TestHasState(t *testing.T) {
+	api, blocks := newPrunedTestAPIBackend(t, 2, 10, transferGenerator(t, 1))
+
+	tests := []struct {
+		number uint64
+		want   bool
+	}{
+		{0, true},  // the genesis state is committed to disk
+		{5, false}, // pruned
+		{10, true}, // the head
+	}
+	for _, tt := range tests {
+		has, err := api.HasState(context.Background(), rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(tt.number)))
+		if err != nil {
+			t.Fatalf("block %d: failed to check state: %v", tt.number, err)
+		}
+		if has != tt.want {
+			t.Errorf("block %d: wrong state availability: have %v, want %v", tt.number, has, tt.want)
+		}
+	}
+	if _, err := api.HasState(context.Background(), rpc.BlockNumberOrHashWithHash(blocks[0].ParentHash(), false)); err != nil {
+		t.Errorf("failed to check state by hash: %v", err)
+	}
+	if _, err := api.HasState(context.Background(), rpc.BlockNumberOrHashWithNumber(11)); err == nil {
+		t.Error("expected error for unknown block")
+	}
+}
+
+func TestGetBlockStateRoots(t *testing.T) {
+	api, blocks := newTestAPIBackend(t, 3, transferGenerator(t, 1))
+
+	for _, block := range blocks {
+		pre, post, err := api.GetBlockStateRoots(context.Background(), rpc.BlockNumberOrHashWithHash(block.Hash(), false))
+		if err != nil {
+			t.Fatalf("block %d: failed to get state roots: %v", block.NumberU64(), err)
+		}
+		parent := api.blockChain().GetHeaderByHash(block.ParentHash())
+		if pre != parent.Root {
+			t.Errorf("block %d: wrong pre-state root: have %v, want %v", block.NumberU64(), pre, parent.Root)
+		}
+		if post != block.Root() {
+			t.Errorf("block %d: wrong post-state root: have %v, want %v", block.NumberU64(), post, block.Root())
+		}
+	}
+	pre, _, err := api.GetBlockStateRoots(context.Background(), rpc.BlockNumberOrHashWithNumber(0))
+	if err != nil {
+		t.Fatalf("failed to get genesis state roots: %v", err)
+	}
+	if pre != types.EmptyRootHash {
+		t.Errorf("wrong genesis pre-state root: have %v, want %v", pre, types.EmptyRootHash)
+	}
+}
+
+func TestGetProof(t *testing.T) {
+	contract := common.Address{0xcc}
+	slot, value := common.Hash{0x01}, common.Hash{0x2a}
+	alloc := core.GenesisAlloc{contract: {Balance: big.NewInt(7), Code: []byte{0x00}, Storage: map[common.Hash]common.Hash{slot: value}}}
+	api, _ := newTestAPIBackendWithAlloc(t, alloc, 1, nil)
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	header := api.CurrentHeader()
+
+	proofDB := func(proof []string) *memorydb.Database {
+		db := memorydb.New()
+		for _, node := range proof {
+			blob := common.FromHex(node)
+			db.Put(crypto.Keccak256(blob), blob)
+		}
+		return db
+	}
+
+	result, err := api.GetProof(context.Background(), contract, []common.Hash{slot}, latest)
+	if err != nil {
+		t.Fatalf("failed to get proof: %v", err)
+	}
+	if result.Balance.ToInt().Cmp(big.NewInt(7)) != 0 || result.CodeHash != crypto.Keccak256Hash([]byte{0x00}) {
+		t.Errorf("wrong account fields: %+v", result)
+	}
+	if account, err := trie.VerifyProof(header.Root, crypto.Keccak256(contract.Bytes()), proofDB(result.AccountProof)); err != nil || account == nil {
+		t.Errorf("invalid account proof: %v", err)
+	}
+	if len(result.StorageProof) != 1 || result.StorageProof[0].Value.ToInt().Cmp(value.Big()) != 0 {
+		t.Fatalf("wrong storage proof: %+v", result.StorageProof)
+	}
+	if stored, err := trie.VerifyProof(result.StorageHash, crypto.Keccak256(slot.Bytes()), proofDB(result.StorageProof[0].Proof)); err != nil || stored == nil {
+		t.Errorf("invalid storage proof: %v", err)
+	}
+
+	// Accounts that don't exist are proven absent, with zeroed fields
+	missing := common.Address{0xdd}
+	result, err = api.GetProof(context.Background(), missing, []common.Hash{slot}, latest)
+	if err != nil {
+		t.Fatalf("failed to get proof of missing account: %v", err)
+	}
+	if result.Balance.ToInt().Sign() != 0 || result.Nonce != 0 || result.StorageHash != types.EmptyRootHash || result.CodeHash != types.EmptyCodeHash {
+		t.Errorf("missing account fields not zeroed: %+v", result)
+	}
+	if account, err := trie.VerifyProof(header.Root, crypto.Keccak256(missing.Bytes()), proofDB(result.AccountProof)); err != nil || account != nil {
+		t.Errorf("missing account not proven absent: %x, %v", account, err)
+	}
+	if len(result.StorageProof) != 1 || result.StorageProof[0].Value.ToInt().Sign() != 0 || len(result.StorageProof[0].Proof) != 0 {
+		t.Errorf("wrong storage proof of missing account: %+v", result.StorageProof)
+	}
+
+	// Pre-Nitro state lives on the classic node
+	api.ChainConfig().ArbitrumChainParams.GenesisBlockNum = header.Number.Uint64() + 1
+	if _, err := api.GetProof(context.Background(), contract, nil, latest); !errors.Is(err, types.ErrUseFallback) {
+		t.Errorf("wrong error for pre-Nitro block: have %v, want %v", err, types.ErrUseFallback)
+	}
+}
+
+func TestIsArbosPrecompile(t *testing.T) {
+	arbSys := common.HexToAddress("0x64")
+	defer func(addresses []common.Address) { vm.PrecompiledAddressesArbitrum = addresses }(vm.PrecompiledAddressesArbitrum)
+	vm.PrecompiledAddressesArbitrum = append(append([]common.Address{}, vm.PrecompiledAddressesBerlin...), arbSys)
+
+	api, _ := newTestAPIBackendWithAlloc(t, testEmitterAlloc, 1, nil)
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+
+	for _, tc := range []struct {
+		address common.Address
+		want    bool
+	}{
+		{arbSys, true},
+		{common.BytesToAddress([]byte{1}), true},
+		{testEmitter, false},
+		{testAddr, false},
+	} {
+		got, err := api.IsArbosPrecompile(context.Background(), tc.address, latest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Errorf("IsArbosPrecompile(%v) = %v, want %v", tc.address, got, tc.want)
+		}
+	}
+
+	if _, err := api.IsArbosPrecompile(context.Background(), arbSys, rpc.BlockNumberOrHashWithNumber(100)); err == nil {
+		t.Error("expected an error for a missing block")
+	}
+}
@@ -0,0 +1,235 @@
+package arbitrum
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/youngqqcn/arbitrum/common"
+	"github.com/youngqqcn/arbitrum/core"
+	"github.com/youngqqcn/arbitrum/core/types"
+	"github.com/youngqqcn/arbitrum/event"
+)
+
+// ErrSubscriptionQueueFull ends a subscription whose consumer fell further behind than SubscriptionQueueLimit events
+var ErrSubscriptionQueueFull = errors.New("subscription queue full, consumer too slow")
+
+// queueFull reports whether a subscription's queue reached the configured limit
+func (a *APIBackend) queueFull(length int) bool {
+	limit := a.b.config.SubscriptionQueueLimit
+	return limit > 0 && length >= limit
+}
+
+// SubscribeNewFullBlocks delivers the full canonical block, including its transactions, on every new head.
+// Blocks are queued internally, so a slow consumer doesn't hold up the head feed; a consumer falling behind
+// by more than SubscriptionQueueLimit blocks is unsubscribed with ErrSubscriptionQueueFull.
+func (a *APIBackend) SubscribeNewFullBlocks(ch chan<- *types.Block) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		headCh := make(chan core.ChainHeadEvent, chainHeadChanSize)
+		headSub := a.SubscribeChainHeadEvent(headCh)
+		defer headSub.Unsubscribe()
+
+		var queue []*types.Block
+		for {
+			var (
+				out  chan<- *types.Block
+				next *types.Block
+			)
+			if len(queue) > 0 {
+				out, next = ch, queue[0]
+			}
+			select {
+			case ev := <-headCh:
+				if a.queueFull(len(queue)) {
+					return ErrSubscriptionQueueFull
+				}
+				queue = append(queue, ev.Block)
+			case out <- next:
+				queue = queue[1:]
+			case err := <-headSub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	})
+}
+
+// SubscribeChainFrom delivers the canonical blocks from startBlock to the head, then keeps delivering new heads.
+// Blocks are delivered in order, each extending the previous one. When a reorg drops delivered blocks,
+// delivery resumes with the block after the common ancestor of the old and new chains.
+// Blocks are read from the chain as they're delivered rather than queued, but a consumer that doesn't take
+// a block while more than SubscriptionQueueLimit new heads arrive is unsubscribed with ErrSubscriptionQueueFull.
+// The subscription ends when ctx is done.
+func (a *APIBackend) SubscribeChainFrom(ctx context.Context, startBlock uint64, ch chan<- *types.Block) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		headCh := make(chan core.ChainHeadEvent, chainHeadChanSize)
+		headSub := a.SubscribeChainHeadEvent(headCh)
+		defer headSub.Unsubscribe()
+
+		bc := a.blockChain()
+		next := startBlock
+		var last *types.Header // the last block delivered
+		for {
+			if last != nil {
+				if ancestor := a.canonicalAncestor(last); ancestor == nil || ancestor.Number.Uint64() < startBlock {
+					next, last = startBlock, nil
+				} else if ancestor.Hash() != last.Hash() {
+					next, last = ancestor.Number.Uint64()+1, ancestor
+				}
+			}
+			newHead := false // whether a head arrived while delivering
+			for head := a.CurrentHeader().Number.Uint64(); next <= head; next++ {
+				block := bc.GetBlockByNumber(next)
+				if block == nil || (last != nil && block.ParentHash() != last.Hash()) {
+					break // the chain is being reorged, wait for the new head
+				}
+				stalled := 0 // heads that arrived while the consumer didn't take the block
+			send:
+				for {
+					select {
+					case ch <- block:
+						break send
+					case <-headCh:
+						newHead = true
+						if a.queueFull(stalled) {
+							return ErrSubscriptionQueueFull
+						}
+						stalled++
+					case err := <-headSub.Err():
+						return err
+					case <-quit:
+						return nil
+					case <-ctx.Done():
+						return ctx.Err()
+					}
+				}
+				last = block.Header()
+			}
+			if newHead {
+				continue
+			}
+			select {
+			case <-headCh:
+			case err := <-headSub.Err():
+				return err
+			case <-quit:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	})
+}
+
+// canonicalAncestor returns the newest ancestor of the header, itself included, that's still canonical,
+// or nil if the header's chain can't be traced back to the canonical one
+func (a *APIBackend) canonicalAncestor(header *types.Header) *types.Header {
+	bc := a.blockChain()
+	for header != nil && bc.GetCanonicalHash(header.Number.Uint64()) != header.Hash() {
+		if header.Number.Sign() == 0 {
+			return nil
+		}
+		header = bc.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+	}
+	return header
+}
+
+// BaseFeeEvent is posted when a new head changes the base fee
+type BaseFeeEvent struct {
+	BlockNumber uint64
+	BlockHash   common.Hash
+	BaseFee     *big.Int
+	PrevBaseFee *big.Int // the base fee of the previous head
+}
+
+// SubscribeBaseFeeChange delivers an event for every new head whose base fee differs from the previous head's.
+// Events are queued internally, so a slow consumer doesn't hold up the head feed; a consumer falling behind
+// by more than SubscriptionQueueLimit events is unsubscribed with ErrSubscriptionQueueFull.
+func (a *APIBackend) SubscribeBaseFeeChange(ch chan<- BaseFeeEvent) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		headCh := make(chan core.ChainHeadEvent, chainHeadChanSize)
+		headSub := a.SubscribeChainHeadEvent(headCh)
+		defer headSub.Unsubscribe()
+
+		prevBaseFee := a.CurrentHeader().BaseFee
+		var queue []BaseFeeEvent
+		for {
+			var (
+				out  chan<- BaseFeeEvent
+				next BaseFeeEvent
+			)
+			if len(queue) > 0 {
+				out, next = ch, queue[0]
+			}
+			select {
+			case ev := <-headCh:
+				baseFee := ev.Block.BaseFee()
+				if baseFee != nil && (prevBaseFee == nil || baseFee.Cmp(prevBaseFee) != 0) {
+					if a.queueFull(len(queue)) {
+						return ErrSubscriptionQueueFull
+					}
+					queue = append(queue, BaseFeeEvent{
+						BlockNumber: ev.Block.NumberU64(),
+						BlockHash:   ev.Block.Hash(),
+						BaseFee:     baseFee,
+						PrevBaseFee: prevBaseFee,
+					})
+				}
+				prevBaseFee = baseFee
+			case out <- next:
+				queue = queue[1:]
+			case err := <-headSub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	})
+}
+
+// SequencerHealthEvent reports the health of the sequencer
+type SequencerHealthEvent struct {
+	Healthy bool
+	Reason  string // why the sequencer is degraded, e.g. it stopped producing blocks or lost its L1 connection
+}
+
+// SubscribeSequencerHealth delivers an event whenever the sequencer goes from healthy to degraded or back.
+// The sequencer is assumed healthy when subscribing. Events are queued internally, so a slow consumer
+// doesn't hold up the health reports; a consumer falling behind by more than SubscriptionQueueLimit events
+// is unsubscribed with ErrSubscriptionQueueFull.
+func (a *APIBackend) SubscribeSequencerHealth(ch chan<- SequencerHealthEvent) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		reportCh := make(chan SequencerHealthEvent, chainHeadChanSize)
+		reportSub := a.b.arb.SubscribeSequencerHealthReports(reportCh)
+		defer reportSub.Unsubscribe()
+
+		healthy := true
+		var queue []SequencerHealthEvent
+		for {
+			var (
+				out  chan<- SequencerHealthEvent
+				next SequencerHealthEvent
+			)
+			if len(queue) > 0 {
+				out, next = ch, queue[0]
+			}
+			select {
+			case report := <-reportCh:
+				if report.Healthy != healthy {
+					if a.queueFull(len(queue)) {
+						return ErrSubscriptionQueueFull
+					}
+					queue = append(queue, report)
+				}
+				healthy = report.Healthy
+			case out <- next:
+				queue = queue[1:]
+			case err := <-reportSub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	})
+}
@@ -0,0 +1,294 @@
+package arbitrum
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/youngqqcn/arbitrum/common"
+	"github.com/youngqqcn/arbitrum/consensus/ethash"
+	"github.com/youngqqcn/arbitrum/core"
+	"github.com/youngqqcn/arbitrum/core/types"
+	"github.com/youngqqcn/arbitrum/params"
+)
+
+func TestSubscribeNewFullBlocks(t *testing.T) {
+	api, _ := newTestAPIBackend(t, 1, nil)
+
+	ch := make(chan *types.Block)
+	sub := api.SubscribeNewFullBlocks(ch)
+	defer sub.Unsubscribe()
+	time.Sleep(50 * time.Millisecond)
+
+	blocks, _ := core.GenerateChain(api.ChainConfig(), api.CurrentBlock(), ethash.NewFaker(), api.ChainDb(), 1, transferGenerator(t, 2))
+	if _, err := api.blockChain().InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+
+	select {
+	case block := <-ch:
+		head := api.CurrentBlock()
+		if block.Hash() != head.Hash() {
+			t.Errorf("wrong block: have %v, want %v", block.Hash(), head.Hash())
+		}
+		if len(block.Transactions()) != 2 {
+			t.Errorf("wrong number of transactions: have %d, want 2", len(block.Transactions()))
+		}
+	case <-time.After(time.Second):
+		t.Fatal("new block not delivered")
+	}
+}
+
+func TestSubscribeNewFullBlocksQueueLimit(t *testing.T) {
+	api, _ := newTestAPIBackend(t, 1, nil)
+	api.b.config.SubscriptionQueueLimit = 2
+
+	// Nobody reads the channel, so every block stays queued
+	sub := api.SubscribeNewFullBlocks(make(chan *types.Block))
+	defer sub.Unsubscribe()
+	time.Sleep(50 * time.Millisecond)
+
+	insertTestBlocks(t, api, 3)
+	select {
+	case err := <-sub.Err():
+		if !errors.Is(err, ErrSubscriptionQueueFull) {
+			t.Errorf("wrong error: have %v, want %v", err, ErrSubscriptionQueueFull)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("slow subscriber not unsubscribed")
+	}
+}
+
+// insertTestBlocks inserts empty blocks one by one, so a head event is posted for each of them
+func insertTestBlocks(t *testing.T, api *APIBackend, n int) {
+	t.Helper()
+	blocks, _ := core.GenerateChain(api.ChainConfig(), api.CurrentBlock(), ethash.NewFaker(), api.ChainDb(), n, nil)
+	for _, block := range blocks {
+		if _, err := api.blockChain().InsertChain(types.Blocks{block}); err != nil {
+			t.Fatalf("failed to insert block: %v", err)
+		}
+	}
+}
+
+func TestSubscribeBaseFeeChange(t *testing.T) {
+	// A tiny base fee stops decreasing once the change would round to zero,
+	// and a tiny gas limit lets a few transfers push it up again.
+	gspec := &core.Genesis{
+		Config:   params.ArbitrumDevTestChainConfig(),
+		Alloc:    core.GenesisAlloc{testAddr: {Balance: testBalance}},
+		BaseFee:  big.NewInt(9),
+		GasLimit: 50000,
+	}
+	api, _ := newTestAPIBackendWithGenesis(t, gspec, nil, 0, nil)
+
+	ch := make(chan BaseFeeEvent)
+	sub := api.SubscribeBaseFeeChange(ch)
+	defer sub.Unsubscribe()
+	time.Sleep(50 * time.Millisecond)
+
+	// The base fee goes 9 (genesis) -> 8 -> 7 -> 7 -> 7 -> 7 -> 8, after two transfers exceed the gas target
+	txsPerBlock := []int{0, 0, 0, 0, 2, 0}
+	signer := types.LatestSigner(gspec.Config)
+	nonce := uint64(0)
+	blocks, _ := core.GenerateChain(gspec.Config, api.CurrentBlock(), ethash.NewFaker(), api.ChainDb(), len(txsPerBlock), func(i int, b *core.BlockGen) {
+		for j := 0; j < txsPerBlock[i]; j++ {
+			tx, err := types.SignTx(types.NewTransaction(nonce, common.Address{0xaa}, big.NewInt(1000), params.TxGas, b.BaseFee(), nil), signer, testKey)
+			if err != nil {
+				t.Fatalf("failed to sign tx: %v", err)
+			}
+			b.AddTx(tx)
+			nonce++
+		}
+	})
+	if _, err := api.blockChain().InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+
+	var want []*types.Block
+	prev := api.blockChain().Genesis().BaseFee()
+	for _, block := range blocks {
+		if block.BaseFee().Cmp(prev) != 0 {
+			want = append(want, block)
+		}
+		prev = block.BaseFee()
+	}
+	if len(want) == 0 || len(want) == len(blocks) {
+		t.Fatalf("test chain must mix changed and unchanged base fees, have %d changes in %d blocks", len(want), len(blocks))
+	}
+	for i, block := range want {
+		select {
+		case ev := <-ch:
+			if ev.BlockHash != block.Hash() {
+				t.Errorf("event %d: wrong block: have %d, want %d", i, ev.BlockNumber, block.NumberU64())
+			}
+			if ev.BaseFee.Cmp(block.BaseFee()) != 0 {
+				t.Errorf("event %d: wrong base fee: have %v, want %v", i, ev.BaseFee, block.BaseFee())
+			}
+			if ev.PrevBaseFee.Cmp(ev.BaseFee) == 0 {
+				t.Errorf("event %d: base fee didn't change: %v", i, ev.BaseFee)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("event %d not delivered", i)
+		}
+	}
+	select {
+	case ev := <-ch:
+		t.Errorf("unexpected event for block %d", ev.BlockNumber)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestSubscribeBaseFeeChangeQueueLimit(t *testing.T) {
+	api, _ := newTestAPIBackend(t, 1, nil)
+	api.b.config.SubscriptionQueueLimit = 2
+
+	sub := api.SubscribeBaseFeeChange(make(chan BaseFeeEvent))
+	defer sub.Unsubscribe()
+	time.Sleep(50 * time.Millisecond)
+
+	// The base fee drops with every empty block
+	insertTestBlocks(t, api, 3)
+	select {
+	case err := <-sub.Err():
+		if !errors.Is(err, ErrSubscriptionQueueFull) {
+			t.Errorf("wrong error: have %v, want %v", err, ErrSubscriptionQueueFull)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("slow subscriber not unsubscribed")
+	}
+}
+
+func TestSubscribeSequencerHealth(t *testing.T) {
+	api, _ := newTestAPIBackend(t, 1, nil)
+	arb := api.b.arb.(*testArbInterface)
+
+	ch := make(chan SequencerHealthEvent)
+	sub := api.SubscribeSequencerHealth(ch)
+	defer sub.Unsubscribe()
+	time.Sleep(50 * time.Millisecond)
+
+	// Repeated reports of the same health aren't transitions
+	reports := []SequencerHealthEvent{
+		{Healthy: true},
+		{Healthy: false, Reason: "no new blocks"},
+		{Healthy: false, Reason: "no new blocks"},
+		{Healthy: true},
+		{Healthy: true},
+	}
+	for _, report := range reports {
+		arb.health.Send(report)
+	}
+	for i, want := range []SequencerHealthEvent{reports[1], reports[3]} {
+		select {
+		case ev := <-ch:
+			if ev != want {
+				t.Errorf("event %d: wrong health: have %+v, want %+v", i, ev, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("event %d: timed out waiting for health transition", i)
+		}
+	}
+	select {
+	case ev := <-ch:
+		t.Errorf("unexpected event without a transition: %+v", ev)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestSubscribeSequencerHealthQueueLimit(t *testing.T) {
+	api, _ := newTestAPIBackend(t, 1, nil)
+	api.b.config.SubscriptionQueueLimit = 2
+	arb := api.b.arb.(*testArbInterface)
+
+	sub := api.SubscribeSequencerHealth(make(chan SequencerHealthEvent))
+	defer sub.Unsubscribe()
+	time.Sleep(50 * time.Millisecond)
+
+	for _, healthy := range []bool{false, true, false} {
+		arb.health.Send(SequencerHealthEvent{Healthy: healthy})
+	}
+	select {
+	case err := <-sub.Err():
+		if !errors.Is(err, ErrSubscriptionQueueFull) {
+			t.Errorf("wrong error: have %v, want %v", err, ErrSubscriptionQueueFull)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("slow subscriber not unsubscribed")
+	}
+}
+
+func TestSubscribeChainFrom(t *testing.T) {
+	api, blocks := newTestAPIBackend(t, 4, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch := make(chan *types.Block)
+	sub := api.SubscribeChainFrom(ctx, 2, ch)
+	defer sub.Unsubscribe()
+
+	expect := func(want ...*types.Block) {
+		t.Helper()
+		for _, block := range want {
+			select {
+			case got := <-ch:
+				if got.Hash() != block.Hash() {
+					t.Fatalf("got block %d %v, want block %d %v", got.NumberU64(), got.Hash(), block.NumberU64(), block.Hash())
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("missing block %d", block.NumberU64())
+			}
+		}
+	}
+
+	// The existing blocks are replayed, then new heads follow
+	expect(blocks[1:]...)
+	live, _ := core.GenerateChain(api.ChainConfig(), blocks[3], ethash.NewFaker(), api.ChainDb(), 2, nil)
+	if _, err := api.blockChain().InsertChain(live); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	expect(live...)
+
+	// A longer fork from block 4 replaces the live blocks, and is delivered from the common ancestor on
+	fork, _ := core.GenerateChain(api.ChainConfig(), blocks[3], ethash.NewFaker(), api.ChainDb(), 3, func(i int, b *core.BlockGen) {
+		b.SetCoinbase(common.Address{0xbb})
+	})
+	if _, err := api.blockChain().InsertChain(fork); err != nil {
+		t.Fatalf("failed to insert fork: %v", err)
+	}
+	if api.CurrentHeader().Hash() != fork[2].Hash() {
+		t.Fatal("fork didn't become canonical")
+	}
+	expect(fork...)
+
+	cancel()
+	select {
+	case err := <-sub.Err():
+		if err != context.Canceled {
+			t.Errorf("wrong error after cancellation: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("subscription didn't end with its context")
+	}
+}
+
+func TestSubscribeChainFromStalledConsumer(t *testing.T) {
+	api, _ := newTestAPIBackend(t, 1, nil)
+	api.b.config.SubscriptionQueueLimit = 2
+
+	// The head block is due right away, but nobody takes it
+	sub := api.SubscribeChainFrom(context.Background(), api.CurrentHeader().Number.Uint64(), make(chan *types.Block))
+	defer sub.Unsubscribe()
+	time.Sleep(50 * time.Millisecond)
+
+	insertTestBlocks(t, api, 3)
+	select {
+	case err := <-sub.Err():
+		if !errors.Is(err, ErrSubscriptionQueueFull) {
+			t.Errorf("wrong error: have %v, want %v", err, ErrSubscriptionQueueFull)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("stalled subscriber not unsubscribed")
+	}
+}
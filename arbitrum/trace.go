@@ -0,0 +1,78 @@
+package arbitrum
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/youngqqcn/arbitrum/common"
+	"github.com/youngqqcn/arbitrum/eth/tracers"
+	_ "github.com/youngqqcn/arbitrum/eth/tracers/native" // registers the prestate tracer
+	"github.com/youngqqcn/arbitrum/rpc"
+)
+
+// defaultTraceReexec is the number of blocks the tracer is willing to go back
+// and reexecute to produce missing historical state necessary to run a specific
+// trace.
+const defaultTraceReexec = uint64(128)
+
+// TxTraceResult is the trace of a single transaction within a traced block
+type TxTraceResult struct {
+	TxHash common.Hash `json:"txHash"`
+	Result interface{} `json:"result,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// TraceBlock re-executes every transaction of a block on top of its parent's state and returns one trace per transaction.
+// The tracing itself is done by the debug tracing API; this only resolves the block and labels each trace with its transaction.
+func (a *APIBackend) TraceBlock(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash, config *tracers.TraceConfig) ([]*TxTraceResult, error) {
+	block, err := a.BlockByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, errors.New("block not found")
+	}
+	traces, err := tracers.NewAPI(a).TraceBlockByHash(ctx, block.Hash(), config)
+	if err != nil {
+		return nil, err
+	}
+	txs := block.Transactions()
+	if len(traces) != len(txs) {
+		return nil, fmt.Errorf("got %d traces for %d transactions", len(traces), len(txs))
+	}
+	results := make([]*TxTraceResult, len(traces))
+	for i, trace := range traces {
+		results[i] = &TxTraceResult{TxHash: txs[i].Hash(), Result: trace.Result, Error: trace.Error}
+	}
+	return results, nil
+}
+
+// GetTransactionTouchedAccounts re-executes a transaction and returns the accounts it read or wrote, sorted by address.
+// This includes the sender, the recipient and the block's coinbase.
+func (a *APIBackend) GetTransactionTouchedAccounts(ctx context.Context, txHash common.Hash) ([]common.Address, error) {
+	tracer := "prestateTracer"
+	res, err := tracers.NewAPI(a).TraceTransaction(ctx, txHash, &tracers.TraceConfig{Tracer: &tracer})
+	if err != nil {
+		return nil, err
+	}
+	raw, ok := res.(json.RawMessage)
+	if !ok {
+		return nil, fmt.Errorf("unexpected prestate tracer result %T", res)
+	}
+	var prestate map[common.Address]json.RawMessage
+	if err := json.Unmarshal(raw, &prestate); err != nil {
+		return nil, err
+	}
+	accounts := make([]common.Address, 0, len(prestate))
+	for addr := range prestate {
+		accounts = append(accounts, addr)
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return bytes.Compare(accounts[i][:], accounts[j][:]) < 0
+	})
+	return accounts, nil
+}
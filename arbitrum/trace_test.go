@@ -0,0 +1,87 @@
+package arbitrum
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/youngqqcn/arbitrum/common"
+	"github.com/youngqqcn/arbitrum/core"
+	"github.com/youngqqcn/arbitrum/core/types"
+	"github.com/youngqqcn/arbitrum/core/vm"
+	"github.com/youngqqcn/arbitrum/eth/tracers/logger"
+	"github.com/youngqqcn/arbitrum/params"
+	"github.com/youngqqcn/arbitrum/rpc"
+)
+
+func TestTraceBlock(t *testing.T) {
+	api, blocks := newTestAPIBackend(t, 1, transferGenerator(t, 3))
+	block := blocks[0]
+
+	results, err := api.TraceBlock(context.Background(), rpc.BlockNumberOrHashWithHash(block.Hash(), false), nil)
+	if err != nil {
+		t.Fatalf("failed to trace block: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("wrong number of traces: have %d, want 3", len(results))
+	}
+	for i, result := range results {
+		if want := block.Transactions()[i].Hash(); result.TxHash != want {
+			t.Errorf("trace %d: wrong tx hash: have %v, want %v", i, result.TxHash, want)
+		}
+		if result.Error != "" {
+			t.Errorf("trace %d: unexpected error: %v", i, result.Error)
+		}
+		raw, ok := result.Result.(json.RawMessage)
+		if !ok {
+			t.Fatalf("trace %d: unexpected result type %T", i, result.Result)
+		}
+		var res logger.ExecutionResult
+		if err := json.Unmarshal(raw, &res); err != nil {
+			t.Fatalf("trace %d: failed to decode result: %v", i, err)
+		}
+		if res.Failed || res.Gas != params.TxGas {
+			t.Errorf("trace %d: unexpected result: failed %v, gas %d", i, res.Failed, res.Gas)
+		}
+	}
+}
+
+func TestGetTransactionTouchedAccounts(t *testing.T) {
+	// The prober contract reads the balance of a third account
+	var (
+		prober  = common.HexToAddress("0x000000000000000000000000000000000000b0b0")
+		checked = common.HexToAddress("0x000000000000000000000000000000000000beef")
+	)
+	code := append(append([]byte{byte(vm.PUSH20)}, checked.Bytes()...), byte(vm.BALANCE), byte(vm.POP), byte(vm.STOP))
+	alloc := core.GenesisAlloc{prober: {Balance: common.Big0, Code: code}}
+	signer := types.LatestSigner(params.ArbitrumDevTestChainConfig())
+	api, blocks := newTestAPIBackendWithAlloc(t, alloc, 1, func(i int, b *core.BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(0, prober, common.Big0, 100000, b.BaseFee(), nil), signer, testKey)
+		if err != nil {
+			t.Fatalf("failed to sign tx: %v", err)
+		}
+		b.AddTx(tx)
+	})
+	block := blocks[0]
+
+	accounts, err := api.GetTransactionTouchedAccounts(context.Background(), block.Transactions()[0].Hash())
+	if err != nil {
+		t.Fatalf("failed to get touched accounts: %v", err)
+	}
+	want := map[common.Address]bool{testAddr: true, prober: true, checked: true, block.Coinbase(): true}
+	if len(accounts) != len(want) {
+		t.Fatalf("wrong number of touched accounts: have %v, want %d", accounts, len(want))
+	}
+	for i, addr := range accounts {
+		if !want[addr] {
+			t.Errorf("unexpected touched account %v", addr)
+		}
+		if i > 0 && bytes.Compare(accounts[i-1][:], addr[:]) >= 0 {
+			t.Errorf("accounts not sorted: %v", accounts)
+		}
+	}
+	if _, err := api.GetTransactionTouchedAccounts(context.Background(), common.Hash{1}); err == nil {
+		t.Error("expected error for unknown tx")
+	}
+}
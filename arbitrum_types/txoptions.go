@@ -2,35 +2,54 @@ package arbitrum_types
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/youngqqcn/arbitrum/common"
 	"github.com/youngqqcn/arbitrum/common/hexutil"
+	"github.com/youngqqcn/arbitrum/core/rawdb"
 	"github.com/youngqqcn/arbitrum/core/state"
+	"github.com/youngqqcn/arbitrum/core/types"
+	"github.com/youngqqcn/arbitrum/ethdb"
 	"github.com/youngqqcn/arbitrum/rpc"
 )
 
 type rejectedError struct {
-	msg string
+	msg   string
+	cause error
 }
 
 func NewRejectedError(msg string) *rejectedError {
 	return &rejectedError{msg: msg}
 }
+
+// NewRejectedErrorWithCause is like NewRejectedError, but keeps the error that caused the rejection for errors.Is and errors.As
+func NewRejectedErrorWithCause(msg string, cause error) *rejectedError {
+	return &rejectedError{msg: msg, cause: cause}
+}
 func (e rejectedError) Error() string { return e.msg }
 func (rejectedError) ErrorCode() int  { return -32003 }
+func (e rejectedError) Unwrap() error { return e.cause }
 
 type limitExceededError struct {
-	msg string
+	msg   string
+	cause error
 }
 
 func NewLimitExceededError(msg string) *limitExceededError {
 	return &limitExceededError{msg: msg}
 }
+
+// NewLimitExceededErrorWithCause is like NewLimitExceededError, but keeps the error that caused it for errors.Is and errors.As
+func NewLimitExceededErrorWithCause(msg string, cause error) *limitExceededError {
+	return &limitExceededError{msg: msg, cause: cause}
+}
 func (e limitExceededError) Error() string { return e.msg }
 func (limitExceededError) ErrorCode() int  { return -32005 }
+func (e limitExceededError) Unwrap() error { return e.cause }
 
 func WrapOptionsCheckError(err error, msg string) error {
 	wrappedMsg := func(e rpc.Error, msg string) string {
@@ -68,15 +87,60 @@ func (r RootHashOrSlots) MarshalJSON() ([]byte, error) {
 	return json.Marshal(r.SlotValue)
 }
 
+// ConditionalOptionsLimits bounds the lookups checking a ConditionalOptions performs. Zero means no limit.
+type ConditionalOptionsLimits struct {
+	// MaxConditions limits the accounts and transactions the options refer to, counting every entry of
+	// knownAccounts, knownNonces, knownBalances, knownCodeHashes and requiredTxHashes
+	MaxConditions int
+	// MaxSlots limits the storage slots of all knownAccounts entries together
+	MaxSlots int
+}
+
+type BalanceBounds struct {
+	BalanceMin *hexutil.Big `json:"balanceMin,omitempty"`
+	BalanceMax *hexutil.Big `json:"balanceMax,omitempty"`
+}
+
 type ConditionalOptions struct {
-	KnownAccounts  map[common.Address]RootHashOrSlots `json:"knownAccounts"`
-	BlockNumberMin *hexutil.Uint64                    `json:"blockNumberMin,omitempty"`
-	BlockNumberMax *hexutil.Uint64                    `json:"blockNumberMax,omitempty"`
-	TimestampMin   *hexutil.Uint64                    `json:"timestampMin,omitempty"`
-	TimestampMax   *hexutil.Uint64                    `json:"timestampMax,omitempty"`
+	KnownAccounts    map[common.Address]RootHashOrSlots `json:"knownAccounts"`
+	BlockNumberMin   *hexutil.Uint64                    `json:"blockNumberMin,omitempty"`
+	BlockNumberMax   *hexutil.Uint64                    `json:"blockNumberMax,omitempty"`
+	TimestampMin     *hexutil.Uint64                    `json:"timestampMin,omitempty"`
+	TimestampMax     *hexutil.Uint64                    `json:"timestampMax,omitempty"`
+	RequiredTxHashes []common.Hash                      `json:"requiredTxHashes,omitempty"`
+	KnownNonces      map[common.Address]hexutil.Uint64  `json:"knownNonces,omitempty"`
+	KnownBalances    map[common.Address]BalanceBounds   `json:"knownBalances,omitempty"`
+	// KnownCodeHashes conditions on the code hash of accounts, e.g. the implementation behind a proxy.
+	// An absent field or a zero hash skips the check; types.EmptyCodeHash also matches accounts that don't exist.
+	KnownCodeHashes map[common.Address]common.Hash `json:"knownCodeHashes,omitempty"`
+}
+
+// CheckLimits rejects options with more conditions than the limits allow, before any of them is looked up
+func (o *ConditionalOptions) CheckLimits(limits ConditionalOptionsLimits) error {
+	if limits.MaxConditions > 0 {
+		conditions := len(o.KnownAccounts) + len(o.KnownNonces) + len(o.KnownBalances) + len(o.KnownCodeHashes) + len(o.RequiredTxHashes)
+		if conditions > limits.MaxConditions {
+			return NewLimitExceededError(fmt.Sprintf("options have %d conditions, the limit is %d", conditions, limits.MaxConditions))
+		}
+	}
+	if limits.MaxSlots > 0 {
+		slots := 0
+		for _, rootHashOrSlots := range o.KnownAccounts {
+			slots += len(rootHashOrSlots.SlotValue)
+		}
+		if slots > limits.MaxSlots {
+			return NewLimitExceededError(fmt.Sprintf("knownAccounts has %d slots, the limit is %d", slots, limits.MaxSlots))
+		}
+	}
+	return nil
 }
 
 func (o *ConditionalOptions) Check(l1BlockNumber uint64, l2Timestamp uint64, statedb *state.StateDB) error {
+	return o.CheckWithContext(context.Background(), l1BlockNumber, l2Timestamp, statedb)
+}
+
+// CheckWithContext is like Check, but gives up between the lookups of the conditions when ctx is done
+func (o *ConditionalOptions) CheckWithContext(ctx context.Context, l1BlockNumber uint64, l2Timestamp uint64, statedb *state.StateDB) error {
 	if o.BlockNumberMin != nil && l1BlockNumber < uint64(*o.BlockNumberMin) {
 		return NewRejectedError("BlockNumberMin condition not met")
 	}
@@ -89,7 +153,60 @@ func (o *ConditionalOptions) Check(l1BlockNumber uint64, l2Timestamp uint64, sta
 	if o.TimestampMax != nil && l2Timestamp > uint64(*o.TimestampMax) {
 		return NewRejectedError("TimestampMax condition not met")
 	}
+	if len(o.RequiredTxHashes) > 0 {
+		// The chain database backing the state also serves the ancient store, which holds old block bodies
+		db, ok := statedb.Database().DiskDB().(ethdb.Reader)
+		if !ok {
+			return errors.New("chain database not available to check RequiredTxHashes")
+		}
+		for _, txHash := range o.RequiredTxHashes {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if tx, _, _, _ := rawdb.ReadTransaction(db, txHash); tx == nil {
+				return NewRejectedError("RequiredTxHashes condition not met")
+			}
+		}
+	}
+	for address, nonce := range o.KnownNonces {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if statedb.GetNonce(address) != uint64(nonce) {
+			return NewRejectedError("Nonce condition not met")
+		}
+	}
+	for address, bounds := range o.KnownBalances {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		balance := statedb.GetBalance(address)
+		if bounds.BalanceMin != nil && balance.Cmp(bounds.BalanceMin.ToInt()) < 0 {
+			return NewRejectedError(fmt.Sprintf("BalanceMin condition not met for address %v", address))
+		}
+		if bounds.BalanceMax != nil && balance.Cmp(bounds.BalanceMax.ToInt()) > 0 {
+			return NewRejectedError(fmt.Sprintf("BalanceMax condition not met for address %v", address))
+		}
+	}
+	for address, codeHash := range o.KnownCodeHashes {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if codeHash == (common.Hash{}) {
+			continue
+		}
+		stored := statedb.GetCodeHash(address)
+		if stored == (common.Hash{}) {
+			stored = types.EmptyCodeHash
+		}
+		if stored != codeHash {
+			return NewRejectedError(fmt.Sprintf("Code hash condition not met for address %v", address))
+		}
+	}
 	for address, rootHashOrSlots := range o.KnownAccounts {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		if rootHashOrSlots.RootHash != nil {
 			trie, err := statedb.StorageTrie(address)
 			if err != nil {
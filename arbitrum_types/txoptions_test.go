@@ -0,0 +1,215 @@
+package arbitrum_types
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/youngqqcn/arbitrum/common"
+	"github.com/youngqqcn/arbitrum/common/hexutil"
+	"github.com/youngqqcn/arbitrum/core/rawdb"
+	"github.com/youngqqcn/arbitrum/core/state"
+	"github.com/youngqqcn/arbitrum/core/types"
+	"github.com/youngqqcn/arbitrum/crypto"
+)
+
+func TestConditionalOptionsKnownNoncesJSON(t *testing.T) {
+	addr := common.Address{0xaa}
+	options := ConditionalOptions{
+		KnownAccounts: map[common.Address]RootHashOrSlots{},
+		KnownNonces:   map[common.Address]hexutil.Uint64{addr: 7},
+	}
+	enc, err := json.Marshal(options)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+	var dec ConditionalOptions
+	if err := json.Unmarshal(enc, &dec); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if !reflect.DeepEqual(dec, options) {
+		t.Errorf("round trip mismatch: have %+v, want %+v", dec, options)
+	}
+
+	// Payloads from before nonce conditions existed decode without any and encode the same as before
+	legacy := `{"knownAccounts":{},"blockNumberMax":"0x10"}`
+	var old ConditionalOptions
+	if err := json.Unmarshal([]byte(legacy), &old); err != nil {
+		t.Fatalf("failed to unmarshal legacy payload: %v", err)
+	}
+	if old.KnownNonces != nil {
+		t.Errorf("unexpected nonce conditions: %v", old.KnownNonces)
+	}
+	reenc, err := json.Marshal(old)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+	if string(reenc) != legacy {
+		t.Errorf("legacy payload changed: have %s, want %s", reenc, legacy)
+	}
+}
+
+func TestConditionalOptionsKnownNoncesCheck(t *testing.T) {
+	statedb, err := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	if err != nil {
+		t.Fatalf("failed to create state: %v", err)
+	}
+	addr := common.Address{0xaa}
+	statedb.SetNonce(addr, 5)
+
+	matching := &ConditionalOptions{KnownNonces: map[common.Address]hexutil.Uint64{addr: 5}}
+	if err := matching.Check(0, 0, statedb); err != nil {
+		t.Errorf("rejected matching nonce: %v", err)
+	}
+	mismatching := &ConditionalOptions{KnownNonces: map[common.Address]hexutil.Uint64{addr: 4}}
+	err = mismatching.Check(0, 0, statedb)
+	if _, ok := err.(*rejectedError); !ok {
+		t.Errorf("wrong error for mismatching nonce: %v", err)
+	}
+}
+
+func TestConditionalOptionsKnownBalancesCheck(t *testing.T) {
+	statedb, err := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	if err != nil {
+		t.Fatalf("failed to create state: %v", err)
+	}
+	addr := common.Address{0xaa}
+	statedb.SetBalance(addr, big.NewInt(100))
+
+	bound := func(v int64) *hexutil.Big {
+		return (*hexutil.Big)(big.NewInt(v))
+	}
+	tests := []struct {
+		name   string
+		bounds BalanceBounds
+		pass   bool
+	}{
+		{"min met", BalanceBounds{BalanceMin: bound(100)}, true},
+		{"min unmet", BalanceBounds{BalanceMin: bound(101)}, false},
+		{"max met", BalanceBounds{BalanceMax: bound(100)}, true},
+		{"max unmet", BalanceBounds{BalanceMax: bound(99)}, false},
+		{"both met", BalanceBounds{BalanceMin: bound(50), BalanceMax: bound(150)}, true},
+		{"exact", BalanceBounds{BalanceMin: bound(100), BalanceMax: bound(100)}, true},
+		{"both above", BalanceBounds{BalanceMin: bound(101), BalanceMax: bound(150)}, false},
+	}
+	for _, tt := range tests {
+		options := &ConditionalOptions{KnownBalances: map[common.Address]BalanceBounds{addr: tt.bounds}}
+		err := options.Check(0, 0, statedb)
+		if tt.pass && err != nil {
+			t.Errorf("%s: rejected: %v", tt.name, err)
+		}
+		if !tt.pass {
+			if _, ok := err.(*rejectedError); !ok {
+				t.Errorf("%s: wrong error: %v", tt.name, err)
+			}
+		}
+	}
+}
+
+func TestConditionalOptionsLimits(t *testing.T) {
+	limits := ConditionalOptionsLimits{MaxConditions: 2, MaxSlots: 3}
+
+	slots := func(n int) RootHashOrSlots {
+		values := make(map[common.Hash]common.Hash)
+		for i := 0; i < n; i++ {
+			values[common.Hash{byte(i)}] = common.Hash{}
+		}
+		return RootHashOrSlots{SlotValue: values}
+	}
+
+	tests := []struct {
+		name    string
+		options *ConditionalOptions
+		pass    bool
+	}{
+		{"within limits", &ConditionalOptions{KnownAccounts: map[common.Address]RootHashOrSlots{{0x01}: slots(1), {0x02}: slots(2)}}, true},
+		{"too many accounts", &ConditionalOptions{KnownAccounts: map[common.Address]RootHashOrSlots{{0x01}: slots(1), {0x02}: slots(1), {0x03}: slots(1)}}, false},
+		{"too many slots", &ConditionalOptions{KnownAccounts: map[common.Address]RootHashOrSlots{{0x01}: slots(2), {0x02}: slots(2)}}, false},
+		{"too many required txs", &ConditionalOptions{RequiredTxHashes: []common.Hash{{0x01}, {0x02}, {0x03}}}, false},
+		{"too many nonces", &ConditionalOptions{KnownNonces: map[common.Address]hexutil.Uint64{{0x01}: 0, {0x02}: 0, {0x03}: 0}}, false},
+		{"too many balances", &ConditionalOptions{KnownBalances: map[common.Address]BalanceBounds{{0x01}: {}, {0x02}: {}, {0x03}: {}}}, false},
+		{"too many code hashes", &ConditionalOptions{KnownCodeHashes: map[common.Address]common.Hash{{0x01}: {}, {0x02}: {}, {0x03}: {}}}, false},
+		{"mixed conditions", &ConditionalOptions{
+			KnownAccounts: map[common.Address]RootHashOrSlots{{0x01}: slots(1)},
+			KnownNonces:   map[common.Address]hexutil.Uint64{{0x01}: 0},
+			KnownBalances: map[common.Address]BalanceBounds{{0x01}: {}},
+		}, false},
+	}
+	for _, tt := range tests {
+		err := tt.options.CheckLimits(limits)
+		if tt.pass && err != nil {
+			t.Errorf("%s: rejected: %v", tt.name, err)
+		}
+		if !tt.pass {
+			if _, ok := err.(*limitExceededError); !ok {
+				t.Errorf("%s: wrong error: %v", tt.name, err)
+			}
+		}
+	}
+
+	unlimited := &ConditionalOptions{RequiredTxHashes: make([]common.Hash, 100)}
+	if err := unlimited.CheckLimits(ConditionalOptionsLimits{}); err != nil {
+		t.Errorf("rejected without limits: %v", err)
+	}
+}
+
+func TestConditionalOptionsCheckWithContext(t *testing.T) {
+	statedb, err := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	if err != nil {
+		t.Fatalf("failed to create state: %v", err)
+	}
+	options := &ConditionalOptions{KnownAccounts: map[common.Address]RootHashOrSlots{
+		{0x01}: {SlotValue: map[common.Hash]common.Hash{{0x01}: {}}},
+	}}
+	if err := options.CheckWithContext(context.Background(), 0, 0, statedb); err != nil {
+		t.Errorf("rejected with live context: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := options.CheckWithContext(ctx, 0, 0, statedb); !errors.Is(err, context.Canceled) {
+		t.Errorf("wrong error with cancelled context: have %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestConditionalOptionsKnownCodeHashesCheck(t *testing.T) {
+	statedb, err := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	if err != nil {
+		t.Fatalf("failed to create state: %v", err)
+	}
+	contract, eoa, missing := common.Address{0x01}, common.Address{0x02}, common.Address{0x03}
+	code := []byte{0x60, 0x00, 0x60, 0x00, 0xf3}
+	statedb.SetCode(contract, code)
+	statedb.SetNonce(eoa, 1)
+
+	tests := []struct {
+		name    string
+		address common.Address
+		hash    common.Hash
+		pass    bool
+	}{
+		{"matching", contract, crypto.Keccak256Hash(code), true},
+		{"mismatching", contract, crypto.Keccak256Hash([]byte{0x00}), false},
+		{"no code", eoa, types.EmptyCodeHash, true},
+		{"no code, expected code", eoa, crypto.Keccak256Hash(code), false},
+		{"no account", missing, types.EmptyCodeHash, true},
+		{"zero hash skips", contract, common.Hash{}, true},
+	}
+	for _, tt := range tests {
+		options := &ConditionalOptions{
+			KnownAccounts:   map[common.Address]RootHashOrSlots{tt.address: {SlotValue: map[common.Hash]common.Hash{{0x01}: {}}}},
+			KnownCodeHashes: map[common.Address]common.Hash{tt.address: tt.hash},
+		}
+		err := options.Check(0, 0, statedb)
+		if tt.pass && err != nil {
+			t.Errorf("%s: rejected: %v", tt.name, err)
+		}
+		if !tt.pass {
+			if _, ok := err.(*rejectedError); !ok {
+				t.Errorf("%s: wrong error: %v", tt.name, err)
+			}
+		}
+	}
+}
@@ -17,10 +17,15 @@
 package main
 
 import (
+	"encoding/json"
+	"io"
+	"sync"
 	"time"
 
+	"github.com/youngqqcn/arbitrum/core/forkid"
 	"github.com/youngqqcn/arbitrum/log"
 	"github.com/youngqqcn/arbitrum/p2p/enode"
+	"golang.org/x/time/rate"
 )
 
 type crawler struct {
@@ -30,16 +35,39 @@ type crawler struct {
 	iters     []enode.Iterator
 	inputIter enode.Iterator
 	ch        chan *enode.Node
+	results   chan enrResult
 	closed    chan struct{}
 
 	// settings
 	revalidateInterval time.Duration
+	forkID             *forkid.ID    // if set, only nodes announcing this "eth" fork ID are kept
+	workers            int           // maximum number of concurrent ENR requests
+	checkpointFile     string        // file the output is saved to during the crawl
+	checkpointInterval time.Duration // how often the output is saved, zero to disable
+	limiter            *rate.Limiter // throttles ENR requests of all workers, nil if unlimited
+	stream             io.Writer     // if set, added and updated nodes are written to it as lines of JSON
+
+	streamMu sync.Mutex
+}
+
+// streamedNode is a line of the crawl stream.
+type streamedNode struct {
+	ID enode.ID `json:"id"`
+	nodeJSON
+}
+
+// enrResult is the outcome of an ENR request.
+type enrResult struct {
+	n   *enode.Node
+	nn  *enode.Node
+	err error
 }
 
 const (
 	nodeRemoved = iota
 	nodeSkipRecent
 	nodeSkipIncompat
+	nodeSkipNoForkID
 	nodeAdded
 	nodeUpdated
 )
@@ -56,7 +84,9 @@ func newCrawler(input nodeSet, disc resolver, iters ...enode.Iterator) *crawler
 		iters:     iters,
 		inputIter: enode.IterNodes(input.nodes()),
 		ch:        make(chan *enode.Node),
+		results:   make(chan enrResult),
 		closed:    make(chan struct{}),
+		workers:   1,
 	}
 	c.iters = append(c.iters, c.inputIter)
 	// Copy input to output initially. Any nodes that fail validation
@@ -77,33 +107,64 @@ func (c *crawler) run(timeout time.Duration) nodeSet {
 	)
 	defer timeoutTimer.Stop()
 	defer statusTicker.Stop()
+	var checkpointCh <-chan time.Time
+	if c.checkpointInterval > 0 && c.checkpointFile != "" && c.checkpointFile != "-" {
+		checkpointTicker := time.NewTicker(c.checkpointInterval)
+		defer checkpointTicker.Stop()
+		checkpointCh = checkpointTicker.C
+	}
 	for _, it := range c.iters {
 		go c.runIterator(doneCh, it)
 	}
 
 	var (
-		added   int
-		updated int
-		skipped int
-		recent  int
-		removed int
+		added    int
+		updated  int
+		skipped  int
+		noForkID int
+		recent   int
+		removed  int
+
+		inflight = make(map[enode.ID]bool) // nodes with an ENR request in progress
+		workers  = c.workers
 	)
+	if workers < 1 {
+		workers = 1
+	}
+	tally := func(status int) {
+		switch status {
+		case nodeSkipIncompat:
+			skipped++
+		case nodeSkipNoForkID:
+			noForkID++
+		case nodeSkipRecent:
+			recent++
+		case nodeRemoved:
+			removed++
+		case nodeAdded:
+			added++
+		default:
+			updated++
+		}
+	}
 loop:
-	for {
+	for liveIters > 0 || len(inflight) > 0 {
+		// Only take new nodes while there's a free worker.
+		var nodes <-chan *enode.Node
+		if len(inflight) < workers {
+			nodes = c.ch
+		}
 		select {
-		case n := <-c.ch:
-			switch c.updateNode(n) {
-			case nodeSkipIncompat:
-				skipped++
-			case nodeSkipRecent:
-				recent++
-			case nodeRemoved:
-				removed++
-			case nodeAdded:
-				added++
-			default:
-				updated++
+		case n := <-nodes:
+			if inflight[n.ID()] || c.recentlyChecked(n) {
+				tally(nodeSkipRecent)
+				continue
 			}
+			inflight[n.ID()] = true
+			go c.requestENR(n)
+		case r := <-c.results:
+			delete(inflight, r.n.ID())
+			tally(c.applyENR(r.n, r.nn, r.err))
 		case it := <-doneCh:
 			if it == c.inputIter {
 				// Enable timeout when we're done revalidating the input nodes.
@@ -112,15 +173,20 @@ loop:
 					timeoutCh = timeoutTimer.C
 				}
 			}
-			if liveIters--; liveIters == 0 {
-				break loop
-			}
+			liveIters--
 		case <-timeoutCh:
 			break loop
+		case <-checkpointCh:
+			if err := saveNodesJSON(c.checkpointFile, c.output); err != nil {
+				log.Error("Failed to checkpoint the crawl", "file", c.checkpointFile, "err", err)
+			} else {
+				log.Debug("Checkpointed the crawl", "file", c.checkpointFile, "len", len(c.output))
+			}
 		case <-statusTicker.C:
 			log.Info("Crawling in progress",
 				"added", added, "updated", updated, "removed", removed,
-				"ignored(recent)", recent, "ignored(incompatible)", skipped)
+				"ignored(recent)", recent, "ignored(incompatible)", skipped, "ignored(no fork ID)", noForkID,
+				"inflight", len(inflight))
 		}
 	}
 
@@ -145,18 +211,55 @@ func (c *crawler) runIterator(done chan<- enode.Iterator, it enode.Iterator) {
 	}
 }
 
-// updateNode updates the info about the given node, and returns a status
-// about what changed
-func (c *crawler) updateNode(n *enode.Node) int {
-	node, ok := c.output[n.ID()]
+// setRequestRate limits the ENR requests to the given number per second, or lifts the limit if it's zero.
+func (c *crawler) setRequestRate(perSecond float64) {
+	if perSecond <= 0 {
+		c.limiter = nil
+		return
+	}
+	c.limiter = rate.NewLimiter(rate.Limit(perSecond), 1)
+}
 
-	// Skip validation of recently-seen nodes.
-	if ok && time.Since(node.LastCheck) < c.revalidateInterval {
-		return nodeSkipRecent
+// throttle waits until the rate limit allows another ENR request.
+// It returns false if the crawl ends first.
+func (c *crawler) throttle() bool {
+	if c.limiter == nil {
+		return true
 	}
+	r := c.limiter.Reserve()
+	timer := time.NewTimer(r.Delay())
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-c.closed:
+		r.Cancel()
+		return false
+	}
+}
 
-	// Request the node record.
+// requestENR requests the record of a node, and hands the result to the main loop.
+func (c *crawler) requestENR(n *enode.Node) {
+	if !c.throttle() {
+		return
+	}
 	nn, err := c.disc.RequestENR(n)
+	select {
+	case c.results <- enrResult{n, nn, err}:
+	case <-c.closed:
+	}
+}
+
+// recentlyChecked reports whether the node was validated within the revalidation interval.
+func (c *crawler) recentlyChecked(n *enode.Node) bool {
+	node, ok := c.output[n.ID()]
+	return ok && time.Since(node.LastCheck) < c.revalidateInterval
+}
+
+// applyENR updates the info about the given node with the outcome of its
+// ENR request, and returns a status about what changed
+func (c *crawler) applyENR(n *enode.Node, nn *enode.Node, err error) int {
+	node := c.output[n.ID()]
 	node.LastCheck = truncNow()
 	status := nodeUpdated
 	if err != nil {
@@ -167,6 +270,10 @@ func (c *crawler) updateNode(n *enode.Node) int {
 		}
 		node.Score /= 2
 	} else {
+		if status, ok := c.checkForkID(nn); !ok {
+			delete(c.output, n.ID())
+			return status
+		}
 		node.N = nn
 		node.Seq = nn.Seq()
 		node.Score++
@@ -185,9 +292,40 @@ func (c *crawler) updateNode(n *enode.Node) int {
 	}
 	log.Debug("Updating node", "id", n.ID(), "seq", n.Seq(), "score", node.Score)
 	c.output[n.ID()] = node
+	c.emit(n.ID(), node)
 	return status
 }
 
+// emit writes an added or updated node to the stream, if there is one.
+func (c *crawler) emit(id enode.ID, node nodeJSON) {
+	if c.stream == nil {
+		return
+	}
+	c.streamMu.Lock()
+	defer c.streamMu.Unlock()
+	if err := json.NewEncoder(c.stream).Encode(streamedNode{id, node}); err != nil {
+		log.Warn("Failed to stream node", "id", id, "err", err)
+	}
+}
+
+// checkForkID reports whether the node passes the fork ID filter. Nodes that don't are
+// skipped as incompatible, or as lacking a fork ID if their record has no "eth" entry.
+func (c *crawler) checkForkID(n *enode.Node) (int, bool) {
+	if c.forkID == nil {
+		return 0, true
+	}
+	var eth ethEntry
+	if n.Load(&eth) != nil {
+		log.Debug("Skipping node without fork ID", "id", n.ID())
+		return nodeSkipNoForkID, false
+	}
+	if eth.ForkID != *c.forkID {
+		log.Debug("Skipping node on another fork", "id", n.ID(), "forkid", forkIDString(eth.ForkID))
+		return nodeSkipIncompat, false
+	}
+	return 0, true
+}
+
 func truncNow() time.Time {
 	return time.Now().UTC().Truncate(1 * time.Second)
 }
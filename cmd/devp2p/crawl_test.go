@@ -0,0 +1,269 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/youngqqcn/arbitrum/core/forkid"
+	"github.com/youngqqcn/arbitrum/p2p/enode"
+	"github.com/youngqqcn/arbitrum/p2p/enr"
+)
+
+// testResolver answers ENR requests with the records it knows.
+type testResolver map[enode.ID]*enode.Node
+
+func (r testResolver) RequestENR(n *enode.Node) (*enode.Node, error) {
+	if nn, ok := r[n.ID()]; ok {
+		return nn, nil
+	}
+	return nil, errors.New("timeout")
+}
+
+func TestCrawlerForkIDFilter(t *testing.T) {
+	var (
+		forkA = forkid.ID{Hash: [4]byte{0xaa, 0xbb, 0xcc, 0xdd}}
+		forkB = forkid.ID{Hash: [4]byte{0x11, 0x22, 0x33, 0x44}, Next: 100}
+	)
+	var (
+		matching = newTestNode(t, ethEntry{ForkID: forkA})
+		other    = newTestNode(t, ethEntry{ForkID: forkB})
+		missing  = newTestNode(t, enr.WithEntry("snap", &capEntry{}))
+	)
+	disc := testResolver{matching.ID(): matching, other.ID(): other, missing.ID(): missing}
+	nodes := []*enode.Node{matching, other, missing}
+
+	// Without a filter, all nodes are added
+	c := newCrawler(nil, disc, enode.IterNodes(nodes))
+	if output := c.run(0); len(output) != len(nodes) {
+		t.Errorf("wrong output size without filter: have %d, want %d", len(output), len(nodes))
+	}
+
+	// With a filter, only the matching node is kept, even if the others were in the input set
+	input := nodeSet{other.ID(): {N: other, Score: 1}, missing.ID(): {N: missing, Score: 1}}
+	c = newCrawler(input, disc, enode.IterNodes(nodes))
+	c.forkID = &forkA
+	output := c.run(0)
+	if len(output) != 1 {
+		t.Fatalf("wrong output size: have %d, want 1", len(output))
+	}
+	if _, ok := output[matching.ID()]; !ok {
+		t.Error("matching node missing from output")
+	}
+
+	// The records are sorted out by the fork ID check
+	c = newCrawler(nil, disc)
+	c.forkID = &forkA
+	tests := []struct {
+		n    *enode.Node
+		want int
+	}{
+		{matching, nodeAdded},
+		{other, nodeSkipIncompat},
+		{missing, nodeSkipNoForkID},
+	}
+	for _, tt := range tests {
+		if status := c.applyENR(tt.n, tt.n, nil); status != tt.want {
+			t.Errorf("node %v: wrong status: have %d, want %d", tt.n.ID(), status, tt.want)
+		}
+	}
+}
+
+func TestParseForkID(t *testing.T) {
+	want := forkid.ID{Hash: [4]byte{0xfc, 0x64, 0xec, 0x04}, Next: 1150000}
+	id, err := parseForkID(forkIDString(want))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != want {
+		t.Errorf("wrong fork ID: have %v, want %v", id, want)
+	}
+	for _, invalid := range []string{"0xfc64ec04", "0xfc64/1", "0xfc64ec04/x", "fc64ec04zz/1"} {
+		if _, err := parseForkID(invalid); err == nil {
+			t.Errorf("invalid fork ID %q accepted", invalid)
+		}
+	}
+}
+
+// slowResolver answers every ENR request with the node itself after a delay.
+type slowResolver struct {
+	delay time.Duration
+	calls int32 // atomic
+}
+
+func (r *slowResolver) RequestENR(n *enode.Node) (*enode.Node, error) {
+	atomic.AddInt32(&r.calls, 1)
+	time.Sleep(r.delay)
+	return n, nil
+}
+
+func TestCrawlerWorkers(t *testing.T) {
+	const delay = 50 * time.Millisecond
+	nodes := make([]*enode.Node, 10)
+	for i := range nodes {
+		nodes[i] = newTestNode(t)
+	}
+	crawl := func(workers int) time.Duration {
+		c := newCrawler(nil, &slowResolver{delay: delay}, enode.IterNodes(nodes))
+		c.workers = workers
+		start := time.Now()
+		output := c.run(0)
+		elapsed := time.Since(start)
+		if len(output) != len(nodes) {
+			t.Errorf("%d workers: wrong output size: have %d, want %d", workers, len(output), len(nodes))
+		}
+		return elapsed
+	}
+
+	serial := crawl(1)
+	if serial < time.Duration(len(nodes))*delay {
+		t.Errorf("serial crawl took %v, less than %v for sequential requests", serial, time.Duration(len(nodes))*delay)
+	}
+	concurrent := crawl(len(nodes))
+	if concurrent >= serial/2 {
+		t.Errorf("concurrent crawl took %v, not much faster than the serial %v", concurrent, serial)
+	}
+}
+
+func TestCrawlerCheckpoint(t *testing.T) {
+	nodes := make([]*enode.Node, 10)
+	for i := range nodes {
+		nodes[i] = newTestNode(t)
+	}
+	file := filepath.Join(t.TempDir(), "nodes.json")
+
+	c := newCrawler(nil, &slowResolver{delay: 20 * time.Millisecond}, enode.IterNodes(nodes))
+	c.checkpointFile = file
+	c.checkpointInterval = 30 * time.Millisecond
+	done := make(chan nodeSet)
+	go func() { done <- c.run(0) }()
+
+	// Wait for a checkpoint while the crawl is still running
+	var checkpoint nodeSet
+	for checkpoint == nil {
+		select {
+		case <-done:
+			t.Fatal("crawl finished without a checkpoint")
+		case <-time.After(5 * time.Millisecond):
+		}
+		if _, err := os.Stat(file); err == nil {
+			checkpoint = loadNodesJSON(file)
+		}
+	}
+	<-done
+	if len(checkpoint) == 0 {
+		t.Fatal("empty checkpoint")
+	}
+	for id, n := range checkpoint {
+		if n.LastCheck.IsZero() {
+			t.Errorf("node %v checkpointed without its last check", id)
+		}
+	}
+
+	// Resuming from the checkpoint skips the nodes validated before the interruption
+	disc := &slowResolver{}
+	c = newCrawler(checkpoint, disc, enode.IterNodes(nodes))
+	c.revalidateInterval = time.Hour
+	output := c.run(0)
+	if len(output) != len(nodes) {
+		t.Errorf("wrong output size after resuming: have %d, want %d", len(output), len(nodes))
+	}
+	if calls := int(atomic.LoadInt32(&disc.calls)); calls != len(nodes)-len(checkpoint) {
+		t.Errorf("wrong number of ENR requests after resuming: have %d, want %d", calls, len(nodes)-len(checkpoint))
+	}
+}
+
+// timedResolver answers every ENR request with the node itself, recording when it was asked.
+type timedResolver struct {
+	mu    sync.Mutex
+	times []time.Time
+}
+
+func (r *timedResolver) RequestENR(n *enode.Node) (*enode.Node, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.times = append(r.times, time.Now())
+	return n, nil
+}
+
+func TestCrawlerRequestRate(t *testing.T) {
+	const perSecond = 50
+	nodes := make([]*enode.Node, 20)
+	for i := range nodes {
+		nodes[i] = newTestNode(t)
+	}
+	disc := new(timedResolver)
+	c := newCrawler(nil, disc, enode.IterNodes(nodes[:10]), enode.IterNodes(nodes[10:]))
+	c.workers = len(nodes)
+	c.setRequestRate(perSecond)
+	if output := c.run(0); len(output) != len(nodes) {
+		t.Fatalf("wrong output size: have %d, want %d", len(output), len(nodes))
+	}
+
+	if len(disc.times) != len(nodes) {
+		t.Fatalf("wrong number of requests: have %d, want %d", len(disc.times), len(nodes))
+	}
+	// The first request uses the burst, the others wait for the bucket to refill
+	elapsed := disc.times[len(disc.times)-1].Sub(disc.times[0])
+	if rate := float64(len(disc.times)-1) / elapsed.Seconds(); rate > perSecond*1.1 {
+		t.Errorf("request rate %.1f/s exceeds the cap of %d/s", rate, perSecond)
+	}
+}
+
+func TestCrawlerStream(t *testing.T) {
+	nodes := make([]*enode.Node, 5)
+	for i := range nodes {
+		nodes[i] = newTestNode(t)
+	}
+	var stream bytes.Buffer
+	c := newCrawler(nil, &slowResolver{}, enode.IterNodes(nodes))
+	c.workers = len(nodes)
+	c.stream = &stream
+	output := c.run(0)
+
+	seen := make(map[enode.ID]bool)
+	scanner := bufio.NewScanner(&stream)
+	for scanner.Scan() {
+		var line streamedNode
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("invalid stream line %q: %v", scanner.Text(), err)
+		}
+		want, ok := output[line.ID]
+		if !ok {
+			t.Fatalf("streamed node %v missing from output", line.ID)
+		}
+		if line.N == nil || line.N.ID() != line.ID {
+			t.Errorf("node %v: wrong streamed record %v", line.ID, line.N)
+		}
+		if line.Score != want.Score || !line.LastResponse.Equal(want.LastResponse) || !line.FirstResponse.Equal(want.FirstResponse) {
+			t.Errorf("node %v: streamed %+v, want %+v", line.ID, line.nodeJSON, want)
+		}
+		seen[line.ID] = true
+	}
+	if len(seen) != len(nodes) {
+		t.Errorf("wrong number of streamed nodes: have %d, want %d", len(seen), len(nodes))
+	}
+}
@@ -18,7 +18,9 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"net"
+	"os"
 	"strconv"
 	"strings"
 	"time"
@@ -26,6 +28,7 @@ import (
 	"github.com/urfave/cli/v2"
 	"github.com/youngqqcn/arbitrum/cmd/devp2p/internal/v4test"
 	"github.com/youngqqcn/arbitrum/common"
+	"github.com/youngqqcn/arbitrum/core/forkid"
 	"github.com/youngqqcn/arbitrum/crypto"
 	"github.com/youngqqcn/arbitrum/internal/flags"
 	"github.com/youngqqcn/arbitrum/p2p/discover"
@@ -78,7 +81,7 @@ var (
 		Name:   "crawl",
 		Usage:  "Updates a nodes.json file with random nodes found in the DHT",
 		Action: discv4Crawl,
-		Flags:  flags.Merge(discoveryNodeFlags, []cli.Flag{crawlTimeoutFlag}),
+		Flags:  flags.Merge(discoveryNodeFlags, []cli.Flag{crawlTimeoutFlag, crawlForkIDFlag, crawlWorkersFlag, crawlCheckpointFlag, crawlRateFlag, crawlStreamFlag}),
 	}
 	discv4TestCommand = &cli.Command{
 		Name:   "test",
@@ -120,6 +123,28 @@ var (
 		Usage: "Time limit for the crawl.",
 		Value: 30 * time.Minute,
 	}
+	crawlForkIDFlag = &cli.StringFlag{
+		Name:  "forkid",
+		Usage: "Only keep nodes announcing this \"eth\" fork ID, given as <hash>/<next> (e.g. 0xfc64ec04/1150000)",
+	}
+	crawlWorkersFlag = &cli.IntFlag{
+		Name:  "workers",
+		Usage: "Number of nodes to request records from concurrently",
+		Value: 1,
+	}
+	crawlCheckpointFlag = &cli.DurationFlag{
+		Name:  "checkpoint",
+		Usage: "Interval at which the node set is saved during the crawl, so an interrupted crawl can resume (0 = only save at the end)",
+		Value: 0,
+	}
+	crawlRateFlag = &cli.Float64Flag{
+		Name:  "rate",
+		Usage: "Maximum number of node record requests per second (0 = unlimited)",
+	}
+	crawlStreamFlag = &cli.StringFlag{
+		Name:  "stream",
+		Usage: "Append every added or updated node to this file as a line of JSON while crawling ('-' for stdout)",
+	}
 	remoteEnodeFlag = &cli.StringFlag{
 		Name:    "remote",
 		Usage:   "Enode of the remote node under test",
@@ -214,11 +239,47 @@ func discv4Crawl(ctx *cli.Context) error {
 	defer disc.Close()
 	c := newCrawler(inputSet, disc, disc.RandomNodes())
 	c.revalidateInterval = 10 * time.Minute
+	c.forkID = crawlForkID(ctx)
+	c.workers = ctx.Int(crawlWorkersFlag.Name)
+	c.checkpointFile = nodesFile
+	c.checkpointInterval = ctx.Duration(crawlCheckpointFlag.Name)
+	c.setRequestRate(ctx.Float64(crawlRateFlag.Name))
+	stream, closeStream := openCrawlStream(ctx)
+	defer closeStream()
+	c.stream = stream
 	output := c.run(ctx.Duration(crawlTimeoutFlag.Name))
 	writeNodesJSON(nodesFile, output)
 	return nil
 }
 
+// crawlForkID returns the fork ID set by the forkid flag, or nil if it's unset.
+func crawlForkID(ctx *cli.Context) *forkid.ID {
+	if !ctx.IsSet(crawlForkIDFlag.Name) {
+		return nil
+	}
+	id, err := parseForkID(ctx.String(crawlForkIDFlag.Name))
+	if err != nil {
+		exit(err)
+	}
+	return &id
+}
+
+// openCrawlStream opens the file set by the stream flag, returning a nil writer if it's unset.
+func openCrawlStream(ctx *cli.Context) (io.Writer, func()) {
+	switch file := ctx.String(crawlStreamFlag.Name); file {
+	case "":
+		return nil, func() {}
+	case "-":
+		return os.Stdout, func() {}
+	default:
+		f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			exit(err)
+		}
+		return f, func() { f.Close() }
+	}
+}
+
 // discv4Test runs the protocol test suite.
 func discv4Test(ctx *cli.Context) error {
 	// Configure test package globals.
@@ -57,6 +57,11 @@ var (
 		Action: discv5Crawl,
 		Flags: flags.Merge(discoveryNodeFlags, []cli.Flag{
 			crawlTimeoutFlag,
+			crawlForkIDFlag,
+			crawlWorkersFlag,
+			crawlCheckpointFlag,
+			crawlRateFlag,
+			crawlStreamFlag,
 		}),
 	}
 	discv5TestCommand = &cli.Command{
@@ -110,6 +115,14 @@ func discv5Crawl(ctx *cli.Context) error {
 	defer disc.Close()
 	c := newCrawler(inputSet, disc, disc.RandomNodes())
 	c.revalidateInterval = 10 * time.Minute
+	c.forkID = crawlForkID(ctx)
+	c.workers = ctx.Int(crawlWorkersFlag.Name)
+	c.checkpointFile = nodesFile
+	c.checkpointInterval = ctx.Duration(crawlCheckpointFlag.Name)
+	c.setRequestRate(ctx.Float64(crawlRateFlag.Name))
+	stream, closeStream := openCrawlStream(ctx)
+	defer closeStream()
+	c.stream = stream
 	output := c.run(ctx.Duration(crawlTimeoutFlag.Name))
 	writeNodesJSON(nodesFile, output)
 	return nil
@@ -21,11 +21,18 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/youngqqcn/arbitrum/common"
+	"github.com/youngqqcn/arbitrum/common/hexutil"
+	"github.com/youngqqcn/arbitrum/core/forkid"
 	"github.com/youngqqcn/arbitrum/p2p/enode"
+	"github.com/youngqqcn/arbitrum/p2p/enr"
+	"github.com/youngqqcn/arbitrum/rlp"
 )
 
 const jsonIndent = "    "
@@ -57,19 +64,44 @@ func loadNodesJSON(file string) nodeSet {
 }
 
 func writeNodesJSON(file string, nodes nodeSet) {
-	nodesJSON, err := json.MarshalIndent(nodes, "", jsonIndent)
-	if err != nil {
-		exit(err)
-	}
 	if file == "-" {
+		nodesJSON, err := json.MarshalIndent(nodes, "", jsonIndent)
+		if err != nil {
+			exit(err)
+		}
 		os.Stdout.Write(nodesJSON)
 		return
 	}
-	if err := os.WriteFile(file, nodesJSON, 0644); err != nil {
+	if err := saveNodesJSON(file, nodes); err != nil {
 		exit(err)
 	}
 }
 
+// saveNodesJSON writes the node set to a file. The set is written to a temporary
+// file first, which then replaces the file, so readers never see a partial write.
+func saveNodesJSON(file string, nodes nodeSet) error {
+	nodesJSON, err := json.MarshalIndent(nodes, "", jsonIndent)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(nodesJSON); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
 // nodes returns the node records contained in the set.
 func (ns nodeSet) nodes() []*enode.Node {
 	result := make([]*enode.Node, 0, len(ns))
@@ -125,3 +157,71 @@ func (ns nodeSet) verify() error {
 	}
 	return nil
 }
+
+// ethEntry is the "eth" ENR entry of nodes running the eth protocol.
+type ethEntry struct {
+	ForkID forkid.ID
+	Tail   []rlp.RawValue `rlp:"tail"`
+}
+
+func (ethEntry) ENRKey() string { return "eth" }
+
+// capabilityKeys are the ENR entries announcing support for a protocol.
+var capabilityKeys = []string{"eth", "les", "snap"}
+
+// nodeSetSummary is a breakdown of a node set by the metadata advertised in the
+// node records.
+type nodeSetSummary struct {
+	Total        int            `json:"total"`
+	ForkIDs      map[string]int `json:"forkIDs"`      // nodes by "eth" fork ID
+	NoForkID     int            `json:"noForkID"`     // nodes without a valid "eth" entry
+	Capabilities map[string]int `json:"capabilities"` // nodes by announced protocol
+}
+
+// summary counts the nodes in the set by fork ID and by announced protocols.
+func (ns nodeSet) summary() nodeSetSummary {
+	s := nodeSetSummary{
+		Total:        len(ns),
+		ForkIDs:      make(map[string]int),
+		Capabilities: make(map[string]int),
+	}
+	for _, n := range ns {
+		var eth ethEntry
+		if n.N.Load(&eth) == nil {
+			s.ForkIDs[forkIDString(eth.ForkID)]++
+		} else {
+			s.NoForkID++
+		}
+		for _, key := range capabilityKeys {
+			var entry struct {
+				Tail []rlp.RawValue `rlp:"tail"`
+			}
+			if n.N.Load(enr.WithEntry(key, &entry)) == nil {
+				s.Capabilities[key]++
+			}
+		}
+	}
+	return s
+}
+
+func forkIDString(id forkid.ID) string {
+	return fmt.Sprintf("%#x/%d", id.Hash[:], id.Next)
+}
+
+// parseForkID parses a fork ID in the format of forkIDString.
+func parseForkID(s string) (forkid.ID, error) {
+	var id forkid.ID
+	hash, next, found := strings.Cut(s, "/")
+	if !found {
+		return id, fmt.Errorf("invalid fork ID %q, want <hash>/<next>", s)
+	}
+	b, err := hexutil.Decode(hash)
+	if err != nil || len(b) != len(id.Hash) {
+		return id, fmt.Errorf("invalid fork ID hash %q", hash)
+	}
+	copy(id.Hash[:], b)
+	if id.Next, err = strconv.ParseUint(next, 10, 64); err != nil {
+		return id, fmt.Errorf("invalid fork ID next block %q", next)
+	}
+	return id, nil
+}
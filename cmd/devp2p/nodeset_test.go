@@ -0,0 +1,85 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/youngqqcn/arbitrum/core/forkid"
+	"github.com/youngqqcn/arbitrum/crypto"
+	"github.com/youngqqcn/arbitrum/p2p/enode"
+	"github.com/youngqqcn/arbitrum/p2p/enr"
+	"github.com/youngqqcn/arbitrum/rlp"
+)
+
+type capEntry struct {
+	Tail []rlp.RawValue `rlp:"tail"`
+}
+
+// newTestNode creates a signed node record holding the given entries.
+func newTestNode(t *testing.T, entries ...enr.Entry) *enode.Node {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r enr.Record
+	for _, e := range entries {
+		r.Set(e)
+	}
+	if err := enode.SignV4(&r, key); err != nil {
+		t.Fatal(err)
+	}
+	n, err := enode.New(enode.ValidSchemes, &r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestNodeSetSummary(t *testing.T) {
+	var (
+		forkA = forkid.ID{Hash: [4]byte{0xaa, 0xbb, 0xcc, 0xdd}}
+		forkB = forkid.ID{Hash: [4]byte{0x11, 0x22, 0x33, 0x44}, Next: 100}
+		ns    = make(nodeSet)
+	)
+	ns.add(
+		newTestNode(t, ethEntry{ForkID: forkA}, enr.WithEntry("snap", &capEntry{})),
+		newTestNode(t, ethEntry{ForkID: forkA}),
+		newTestNode(t, ethEntry{ForkID: forkB}, enr.WithEntry("snap", &capEntry{})),
+		newTestNode(t, enr.WithEntry("les", &capEntry{})),
+		newTestNode(t),
+	)
+
+	want := nodeSetSummary{
+		Total: 5,
+		ForkIDs: map[string]int{
+			"0xaabbccdd/0":   2,
+			"0x11223344/100": 1,
+		},
+		NoForkID: 2,
+		Capabilities: map[string]int{
+			"eth":  3,
+			"snap": 2,
+			"les":  1,
+		},
+	}
+	if have := ns.summary(); !reflect.DeepEqual(have, want) {
+		t.Errorf("wrong summary:\nhave %+v\nwant %+v", have, want)
+	}
+}
@@ -65,9 +65,29 @@ func nodesetInfo(ctx *cli.Context) error {
 	ns := loadNodesJSON(ctx.Args().First())
 	fmt.Printf("Set contains %d nodes.\n", len(ns))
 	showAttributeCounts(ns)
+	showSummary(ns)
 	return nil
 }
 
+// showSummary prints the distribution of fork IDs and protocols in a node set.
+func showSummary(ns nodeSet) {
+	summary := ns.summary()
+	printCounts := func(title string, counts map[string]int) {
+		keys := make([]string, 0, len(counts))
+		for key := range counts {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		fmt.Println(title)
+		for _, key := range keys {
+			fmt.Printf(" %s: %d\n", key, counts[key])
+		}
+	}
+	printCounts("Fork ID counts:", summary.ForkIDs)
+	fmt.Printf(" (none): %d\n", summary.NoForkID)
+	printCounts("Protocol counts:", summary.Capabilities)
+}
+
 // showAttributeCounts prints the distribution of ENR attributes in a node set.
 func showAttributeCounts(ns nodeSet) {
 	attrcount := make(map[string]int)
@@ -18,6 +18,7 @@ package core
 
 import (
 	"context"
+	"math/big"
 
 	"github.com/youngqqcn/arbitrum/common"
 	"github.com/youngqqcn/arbitrum/core/state"
@@ -44,6 +45,16 @@ var InterceptRPCMessage = func(
 // Gets ArbOS's maximum intended gas per second
 var GetArbOSSpeedLimitPerSecond func(statedb *state.StateDB) (uint64, error)
 
+// Gets ArbOS's current estimate of the L1 base fee
+var GetArbOSL1BaseFeeEstimate func(statedb *state.StateDB) (*big.Int, error)
+
+// Gets ArbOS's current gas backlog and the inertia with which the base fee reacts to it
+var GetArbOSGasBacklog func(statedb *state.StateDB) (backlog uint64, pricingInertia uint64, err error)
+
+// Creates the internal transaction ArbOS starts every block with, given the L1 base fee and block number it's based on
+// and the previous block's header
+var InternalTxStartBlock func(chainId *big.Int, l1BaseFee *big.Int, l1BlockNum uint64, header, lastHeader *types.Header) *types.ArbitrumInternalTx
+
 // Allows ArbOS to update the gas cap so that it ignores the message's specific L1 poster costs.
 var InterceptRPCGasCap = func(gascap *uint64, msg types.Message, header *types.Header, statedb *state.StateDB) {}
 
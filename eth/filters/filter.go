@@ -19,6 +19,7 @@ package filters
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math/big"
 
 	"github.com/youngqqcn/arbitrum/common"
@@ -153,6 +154,9 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 	if f.end, err = resolveSpecial(f.end); err != nil {
 		return nil, err
 	}
+	if limit := f.sys.cfg.RangeLimit; limit > 0 && f.end >= f.begin && uint64(f.end-f.begin) >= limit {
+		return nil, fmt.Errorf("block range %d-%d exceeds the limit of %d blocks", f.begin, f.end, limit)
+	}
 	// Gather all indexed logs, and finish with non indexed ones
 	var (
 		logs           []*types.Log
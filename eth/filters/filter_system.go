@@ -43,6 +43,7 @@ import (
 type Config struct {
 	LogCacheSize int           // maximum number of cached blocks (default: 32)
 	Timeout      time.Duration // how long filters stay active (default: 5min)
+	RangeLimit   uint64        // maximum number of blocks a log query may span (0 = no limit)
 }
 
 func (cfg Config) withDefaults() Config {
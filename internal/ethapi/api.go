@@ -88,10 +88,12 @@ func (s *EthereumAPI) MaxPriorityFeePerGas(ctx context.Context) (*hexutil.Big, e
 }
 
 type feeHistoryResult struct {
-	OldestBlock  *hexutil.Big     `json:"oldestBlock"`
-	Reward       [][]*hexutil.Big `json:"reward,omitempty"`
-	BaseFee      []*hexutil.Big   `json:"baseFeePerGas,omitempty"`
-	GasUsedRatio []float64        `json:"gasUsedRatio"`
+	OldestBlock      *hexutil.Big     `json:"oldestBlock"`
+	Reward           [][]*hexutil.Big `json:"reward,omitempty"`
+	BaseFee          []*hexutil.Big   `json:"baseFeePerGas,omitempty"`
+	GasUsedRatio     []float64        `json:"gasUsedRatio"`
+	BlobBaseFee      []*hexutil.Big   `json:"baseFeePerBlobGas,omitempty"`
+	BlobGasUsedRatio []float64        `json:"blobGasUsedRatio"`
 }
 
 // FeeHistory returns the fee market history.
@@ -118,7 +120,14 @@ func (s *EthereumAPI) FeeHistory(ctx context.Context, blockCount math.HexOrDecim
 		for i, v := range baseFee {
 			results.BaseFee[i] = (*hexutil.Big)(v)
 		}
+		// Blob transactions aren't supported, so the blob base fee and usage are zero,
+		// with the same length as the base fees, including any predicted value
+		results.BlobBaseFee = make([]*hexutil.Big, len(baseFee))
+		for i := range results.BlobBaseFee {
+			results.BlobBaseFee[i] = (*hexutil.Big)(new(big.Int))
+		}
 	}
+	results.BlobGasUsedRatio = make([]float64, len(gasUsed))
 	return results, nil
 }
 
@@ -191,6 +191,8 @@ type PeerInfo struct {
 		UserAgent string
 		Origin    string
 		Host      string
+		// RequestID is the X-Request-ID header, used to correlate a request across services.
+		RequestID string
 	}
 }
 